@@ -1,26 +1,92 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"net/http"
 	"strings"
+	"time"
 
 	sdk "gitee.com/openeuler/go-gitee/gitee"
 	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
 )
 
 const (
-	retestCommand     = "/retest"
-	msgNotSetReviewer = "**@%s** Thank you for submitting a PullRequest. It is detected that you have not set a reviewer, please set a one."
+	retestCommand    = "/retest"
+	ciTriggerTimeout = 10 * time.Second
 )
 
-func (bot *robot) doRetest(e *sdk.PullRequestEvent) error {
+func (bot *robot) doRetest(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
 	if giteeclient.GetPullRequestAction(e) != giteeclient.PRActionChangedSourceBranch {
 		return nil
 	}
 
+	tc := &cfg.CITrigger
 	pr := giteeclient.GetPRInfoByPREvent(e)
 
-	return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, retestCommand)
+	if tc.Disabled || (len(tc.RequiredLabels) > 0 && !pr.Labels.HasAny(tc.RequiredLabels...)) {
+		return nil
+	}
+
+	if tc.RequireOkToTest && !pr.Labels.Has(okToTestLabel) {
+		v, err := bot.isTrustedContributor(pr, cfg, log)
+		if err != nil {
+			return err
+		}
+
+		if !v {
+			return bot.markNeedsOkToTest(pr, cfg, false)
+		}
+	}
+
+	return bot.triggerCI(pr, tc)
+}
+
+func (bot *robot) triggerCI(pr giteeclient.PRInfo, tc *ciTriggerConfig) error {
+	if tc.Endpoint != "" {
+		return callCITrigger(tc.Endpoint, pr)
+	}
+
+	merr := utils.NewMultiErrors()
+	for _, c := range tc.Comments {
+		if err := bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, c); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+// callCITrigger posts the information of PR to the endpoint of CI.
+func callCITrigger(endpoint string, pr giteeclient.PRInfo) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"org":      pr.Org,
+		"repo":     pr.Repo,
+		"number":   pr.Number,
+		"author":   pr.Author,
+		"base_ref": pr.BaseRef,
+		"head_sha": pr.HeadSHA,
+	})
+	if err != nil {
+		return err
+	}
+
+	cli := http.Client{Timeout: ciTriggerTimeout}
+
+	resp, err := cli.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("trigger ci for pr %s/%s/%d, status: %s", pr.Org, pr.Repo, pr.Number, resp.Status)
+	}
+
+	return nil
 }
 
 func (bot *robot) checkReviewer(e *sdk.PullRequestEvent, cfg *botConfig) error {
@@ -34,10 +100,13 @@ func (bot *robot) checkReviewer(e *sdk.PullRequestEvent, cfg *botConfig) error {
 
 	pr := giteeclient.GetPRInfoByPREvent(e)
 
-	return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, fmt.Sprintf(msgNotSetReviewer, pr.Author))
+	return bot.cli.CreatePRComment(
+		pr.Org, pr.Repo, pr.Number,
+		cfg.message(msgNotSetReviewer, msgData{"Author": pr.Author}),
+	)
 }
 
-func (bot *robot) clearLabel(e *sdk.PullRequestEvent) error {
+func (bot *robot) clearLabel(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
 	if giteeclient.GetPullRequestAction(e) != giteeclient.PRActionChangedSourceBranch {
 		return nil
 	}
@@ -49,14 +118,22 @@ func (bot *robot) clearLabel(e *sdk.PullRequestEvent) error {
 		v = append(v, approvedLabel)
 	}
 
+	if pr.Labels.Has(selfApprovedLabel) {
+		v = append(v, selfApprovedLabel)
+	}
+
+	if pr.Labels.Has(staleLabel) {
+		v = append(v, staleLabel)
+	}
+
 	if len(v) > 0 {
 		if err := bot.cli.RemovePRLabels(pr.Org, pr.Repo, pr.Number, v); err != nil {
 			return err
 		}
 
-		return bot.cli.CreatePRComment(
-			pr.Org, pr.Repo, pr.Number,
-			fmt.Sprintf(commentClearLabel, strings.Join(v, ", ")),
+		return bot.notify(
+			pr.Org, pr.Repo, pr.Number, cfg,
+			cfg.message(commentClearLabel, msgData{"Labels": strings.Join(v, ", ")}), log,
 		)
 	}
 
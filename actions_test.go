@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+)
+
+func TestTriggerCI(t *testing.T) {
+	pr := giteeclient.PRInfo{
+		Org: "org", Repo: "repo", Number: 1, Author: "author", BaseRef: "master", HeadSHA: "head",
+	}
+
+	var received map[string]interface{}
+	status := http.StatusOK
+
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = nil
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.WriteHeader(status)
+	}))
+	defer s.Close()
+
+	cases := []struct {
+		name     string
+		tc       ciTriggerConfig
+		status   int
+		comments []string
+		called   bool
+		err      bool
+	}{
+		{
+			name:     "comments",
+			tc:       ciTriggerConfig{Comments: []string{retestCommand, "/check-cla"}},
+			comments: []string{retestCommand, "/check-cla"},
+		},
+		{
+			name:   "endpoint",
+			tc:     ciTriggerConfig{Endpoint: s.URL, Comments: []string{retestCommand}},
+			status: http.StatusAccepted,
+			called: true,
+		},
+		{
+			name:   "endpoint failed",
+			tc:     ciTriggerConfig{Endpoint: s.URL},
+			status: http.StatusInternalServerError,
+			called: true,
+			err:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		received = nil
+		status = tc.status
+
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		if err := bot.triggerCI(pr, &tc.tc); (err != nil) != tc.err {
+			t.Errorf("%s: err = %v", tc.name, err)
+		}
+
+		if strings.Join(cli.created, ",") != strings.Join(tc.comments, ",") {
+			t.Errorf("%s: comments = %q, want %q", tc.name, cli.created, tc.comments)
+		}
+
+		if called := received != nil; called != tc.called {
+			t.Fatalf("%s: called = %t, want %t", tc.name, called, tc.called)
+		}
+
+		if !tc.called {
+			continue
+		}
+
+		want := map[string]interface{}{
+			"org": "org", "repo": "repo", "number": float64(1),
+			"author": "author", "base_ref": "master", "head_sha": "head",
+		}
+		for k, v := range want {
+			if received[k] != v {
+				t.Errorf("%s: %s = %v, want %v", tc.name, k, received[k], v)
+			}
+		}
+	}
+}
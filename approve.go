@@ -1,61 +1,56 @@
 package main
 
 import (
-	"fmt"
-	"regexp"
+	"strings"
 
-	sdk "gitee.com/openeuler/go-gitee/gitee"
 	"github.com/opensourceways/community-robot-lib/giteeclient"
 	"github.com/sirupsen/logrus"
 )
 
-const approvedLabel = "approved"
+const (
+	approvedLabel = "approved"
 
-var (
-	regAddApprove    = regexp.MustCompile(`(?mi)^/approve\s*$`)
-	regRemoveApprove = regexp.MustCompile(`(?mi)^/approve cancel\s*$`)
+	// selfApprovedLabel is added together with the approved label when the PR is approved by its author.
+	selfApprovedLabel = "self-approved"
 )
 
-func (bot *robot) handleApprove(e *sdk.NoteEvent, cfg *botConfig, log *logrus.Entry) error {
-	ne := giteeclient.NewPRNoteEvent(e)
-
-	if !ne.IsPullRequest() || !ne.IsPROpen() || !ne.IsCreatingCommentEvent() {
-		return nil
-	}
-
-	if regAddApprove.MatchString(ne.GetComment()) {
-		return bot.AddApprove(cfg, ne, log)
-	}
-
-	if regRemoveApprove.MatchString(ne.GetComment()) {
-		return bot.removeApprove(cfg, ne, log)
-	}
-
-	return nil
-}
-
 func (bot *robot) AddApprove(cfg *botConfig, e giteeclient.PRNoteEvent, log *logrus.Entry) error {
 	pr := e.GetPRInfo()
 	commenter := e.GetCommenter()
 
-	v, err := bot.hasPermission(commenter, pr, log)
-	if err != nil {
-		return err
-	}
-
-	if !v {
-		return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, fmt.Sprintf(
-			commentNoPermissionForLabel, commenter, "add", approvedLabel,
-		))
+	isSelf := strings.EqualFold(pr.Author, commenter)
+	if isSelf && cfg.SelfApprovalPolicy == selfApprovalForbid {
+		return bot.cli.CreatePRComment(
+			pr.Org, pr.Repo, pr.Number,
+			cfg.message(commentSelfApprovalForbidden, msgData{"Commenter": commenter}),
+		)
 	}
 
 	if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, approvedLabel); err != nil {
 		return err
 	}
 
-	err = bot.cli.CreatePRComment(
-		pr.Org, pr.Repo, pr.Number,
-		fmt.Sprintf(commentAddLabel, approvedLabel, commenter),
+	// the extra lgtm needed by the self approval is checked when merging.
+	// The approval of others takes the place of the self approval, but not vice versa.
+	switch {
+	case isSelf && !pr.Labels.Has(approvedLabel):
+		if err := bot.createLabelIfNeed(pr.Org, pr.Repo, selfApprovedLabel); err != nil {
+			log.WithError(err).Errorf("create repo label: %s", selfApprovedLabel)
+		}
+
+		if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, selfApprovedLabel); err != nil {
+			return err
+		}
+
+	case !isSelf && pr.Labels.Has(selfApprovedLabel):
+		if err := bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, selfApprovedLabel); err != nil {
+			return err
+		}
+	}
+
+	err := bot.notify(
+		pr.Org, pr.Repo, pr.Number, cfg,
+		cfg.message(commentAddLabel, msgData{"Label": approvedLabel, "Commenter": commenter}), log,
 	)
 	if err != nil {
 		log.Error(err)
@@ -68,24 +63,17 @@ func (bot *robot) removeApprove(cfg *botConfig, e giteeclient.PRNoteEvent, log *
 	pr := e.GetPRInfo()
 	commenter := e.GetCommenter()
 
-	v, err := bot.hasPermission(commenter, pr, log)
-	if err != nil {
-		return err
+	l := []string{approvedLabel}
+	if pr.Labels.Has(selfApprovedLabel) {
+		l = append(l, selfApprovedLabel)
 	}
 
-	if !v {
-		return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, fmt.Sprintf(
-			commentNoPermissionForLabel, commenter, "remove", approvedLabel,
-		))
-	}
-
-	err = bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, approvedLabel)
-	if err != nil {
+	if err := bot.cli.RemovePRLabels(pr.Org, pr.Repo, pr.Number, l); err != nil {
 		return err
 	}
 
-	return bot.cli.CreatePRComment(
-		pr.Org, pr.Repo, pr.Number,
-		fmt.Sprintf(commentRemovedLabel, approvedLabel, commenter),
+	return bot.notify(
+		pr.Org, pr.Repo, pr.Number, cfg,
+		cfg.message(commentRemovedLabel, msgData{"Label": approvedLabel, "Commenter": commenter}), log,
 	)
 }
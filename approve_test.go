@@ -0,0 +1,108 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+)
+
+func TestAddApprove(t *testing.T) {
+	cases := []struct {
+		name      string
+		commenter string
+		labels    []string
+		policy    selfApprovalPolicy
+		added     []string
+		removed   []string
+		forbidden bool
+	}{
+		{
+			name:      "self approved",
+			commenter: "author",
+			added:     []string{approvedLabel, selfApprovedLabel},
+		},
+		{
+			// the approval of others is not taken over by the self approval.
+			name:      "self approved after others",
+			commenter: "author",
+			labels:    []string{approvedLabel},
+			added:     []string{approvedLabel},
+		},
+		{
+			name:      "approved by others",
+			commenter: "alice",
+			added:     []string{approvedLabel},
+		},
+		{
+			name:      "approved by others after self approval",
+			commenter: "alice",
+			labels:    []string{approvedLabel, selfApprovedLabel},
+			added:     []string{approvedLabel},
+			removed:   []string{selfApprovedLabel},
+		},
+		{
+			name:      "self approval forbidden",
+			commenter: "Author",
+			policy:    selfApprovalForbid,
+			forbidden: true,
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{SelfApprovalPolicy: tc.policy}
+		cfg.setDefault()
+
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		e := giteeclient.NewPRNoteEvent(newTestNoteEvent(tc.commenter, "/approve", tc.labels...))
+		if err := bot.AddApprove(cfg, e, newTestLog()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if strings.Join(cli.added, ",") != strings.Join(tc.added, ",") ||
+			strings.Join(cli.removed, ",") != strings.Join(tc.removed, ",") {
+			t.Errorf(
+				"%s: added = %v, removed = %v, want %v, %v",
+				tc.name, cli.added, cli.removed, tc.added, tc.removed,
+			)
+		}
+
+		if tc.forbidden && (len(cli.created) != 1 || !strings.Contains(cli.created[0], "@Author")) {
+			t.Errorf("%s: comments = %q", tc.name, cli.created)
+		}
+	}
+}
+
+func TestRemoveApprove(t *testing.T) {
+	cases := []struct {
+		name    string
+		labels  []string
+		removed []string
+	}{
+		{name: "approved", labels: []string{approvedLabel}, removed: []string{approvedLabel}},
+		{
+			name:    "self approved",
+			labels:  []string{approvedLabel, selfApprovedLabel},
+			removed: []string{approvedLabel, selfApprovedLabel},
+		},
+	}
+
+	cfg := &botConfig{}
+	cfg.setDefault()
+
+	for _, tc := range cases {
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		e := giteeclient.NewPRNoteEvent(newTestNoteEvent("alice", "/approve cancel", tc.labels...))
+		if err := bot.removeApprove(cfg, e, newTestLog()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if strings.Join(cli.removed, ",") != strings.Join(tc.removed, ",") {
+			t.Errorf("%s: removed = %v, want %v", tc.name, cli.removed, tc.removed)
+		}
+	}
+}
@@ -0,0 +1,457 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+var errCherryPickConflict = errors.New("cherry-pick conflicts")
+
+// credentialHelper reads the credential from the environment variables,
+// so that the token is neither in the remote url nor in the config of repo.
+const credentialHelper = `!f() { test "$1" = get && echo "username=$GIT_USERNAME" && echo "password=$GIT_PASSWORD"; }; f`
+
+// cherryPickQueueSize is the maximum number of cherry-picks waiting for the worker.
+const cherryPickQueueSize = 100
+
+type cherryPickOptions struct {
+	workDir   string
+	remoteURL string
+	userName  string
+	userEmail string
+}
+
+func (o *cherryPickOptions) addFlags(fs *flag.FlagSet) {
+	fs.StringVar(&o.workDir, "cherry-pick-workdir", "", "The directory to clone the repos for /cherry-pick, it is disabled when empty")
+	fs.StringVar(&o.remoteURL, "cherry-pick-remote", "https://gitee.com/%s/%s.git", "The format of the git remote url of repo, which takes the org and repo")
+	fs.StringVar(&o.userName, "cherry-pick-git-name", "", "The name of git user to cherry-pick the commits")
+	fs.StringVar(&o.userEmail, "cherry-pick-git-email", "", "The email of git user to cherry-pick the commits")
+}
+
+func (o *cherryPickOptions) validate() error {
+	if o.workDir == "" {
+		return nil
+	}
+
+	if strings.Count(o.remoteURL, "%s") != 2 {
+		return fmt.Errorf("cherry-pick-remote must take the org and repo")
+	}
+
+	if o.userName == "" || o.userEmail == "" {
+		return fmt.Errorf("cherry-pick-git-name and cherry-pick-git-email must be set")
+	}
+
+	return nil
+}
+
+// cherryPicker cherry-picks the commits of PR onto another branch in the
+// local working copy of repo and pushes them as a new branch.
+// The cherry-picks are run by a worker, so that the webhooks are not blocked by git.
+type cherryPicker struct {
+	opt      cherryPickOptions
+	getToken func() []byte
+
+	locks    map[string]*sync.Mutex
+	locksMut sync.Mutex
+
+	tasks   chan func()
+	stopped bool
+	stopMut sync.RWMutex
+	wg      sync.WaitGroup
+}
+
+func newCherryPicker(opt cherryPickOptions, getToken func() []byte) *cherryPicker {
+	return &cherryPicker{
+		opt:      opt,
+		getToken: getToken,
+		locks:    map[string]*sync.Mutex{},
+		tasks:    make(chan func(), cherryPickQueueSize),
+	}
+}
+
+func (c *cherryPicker) start() {
+	c.wg.Add(1)
+
+	go func() {
+		defer c.wg.Done()
+
+		for f := range c.tasks {
+			f()
+		}
+	}()
+}
+
+// stop waits for the queued cherry-picks to finish.
+func (c *cherryPicker) stop() {
+	c.stopMut.Lock()
+	c.stopped = true
+	close(c.tasks)
+	c.stopMut.Unlock()
+
+	c.wg.Wait()
+}
+
+// enqueue returns error if the queue is full or the worker is stopped.
+func (c *cherryPicker) enqueue(f func()) error {
+	c.stopMut.RLock()
+	defer c.stopMut.RUnlock()
+
+	if c.stopped {
+		return errors.New("the cherry-pick worker is stopped")
+	}
+
+	select {
+	case c.tasks <- f:
+		return nil
+	default:
+		return errors.New("too many cherry-picks are waiting")
+	}
+}
+
+type cherryPickRequest struct {
+	org     string
+	repo    string
+	number  int32
+	target  string
+	branch  string
+	commits []string
+}
+
+// pick returns the conflicting files with errCherryPickConflict if the commits
+// can't be cherry-picked cleanly.
+func (c *cherryPicker) pick(req cherryPickRequest, login string) ([]string, error) {
+	if err := checkBranchName(req.target); err != nil {
+		return nil, err
+	}
+
+	l := c.lockRepo(req.org, req.repo)
+	defer l.Unlock()
+
+	token := string(c.getToken())
+
+	g := gitRunner{
+		dir:    filepath.Join(c.opt.workDir, req.org, req.repo),
+		secret: token,
+		env:    []string{"GIT_USERNAME=" + login, "GIT_PASSWORD=" + token},
+	}
+
+	if err := g.prepare(fmt.Sprintf(c.opt.remoteURL, req.org, req.repo)); err != nil {
+		return nil, err
+	}
+
+	prRef := fmt.Sprintf("pull/%d/head", req.number)
+	if _, err := g.run("fetch", "origin", "--", req.target, "+refs/"+prRef+":refs/remotes/origin/"+prRef); err != nil {
+		return nil, err
+	}
+
+	if _, err := g.run("checkout", "-f", "-B", req.branch, "origin/"+req.target, "--"); err != nil {
+		return nil, err
+	}
+
+	args := []string{
+		"-c", "user.name=" + c.opt.userName,
+		"-c", "user.email=" + c.opt.userEmail,
+		"cherry-pick", "-x", "--",
+	}
+
+	if _, err := g.run(append(args, req.commits...)...); err != nil {
+		out, _ := g.run("diff", "--name-only", "--diff-filter=U")
+		_, _ = g.run("cherry-pick", "--abort")
+
+		if files := strings.Fields(out); len(files) > 0 {
+			return files, errCherryPickConflict
+		}
+
+		return nil, err
+	}
+
+	_, err := g.run("push", "-f", "origin", "--", req.branch)
+
+	return nil, err
+}
+
+func (c *cherryPicker) lockRepo(org, repo string) *sync.Mutex {
+	k := org + "/" + repo
+
+	c.locksMut.Lock()
+	l, ok := c.locks[k]
+	if !ok {
+		l = new(sync.Mutex)
+		c.locks[k] = l
+	}
+	c.locksMut.Unlock()
+
+	l.Lock()
+
+	return l
+}
+
+// checkBranchName rejects the names which are not valid branches or may be taken as the options of git.
+func checkBranchName(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("invalid branch name: %q", name)
+	}
+
+	// it runs outside of any repo, so that the names like @{-1} are not expanded.
+	cmd := exec.Command("git", "check-ref-format", "--branch", name)
+	cmd.Dir = os.TempDir()
+
+	if out, err := cmd.Output(); err != nil || strings.TrimSpace(string(out)) != name {
+		return fmt.Errorf("invalid branch name: %q", name)
+	}
+
+	return nil
+}
+
+type gitRunner struct {
+	dir string
+
+	// secret is hidden in the output of git.
+	secret string
+
+	// env is passed to git in addition to the environment of process, such as the credential.
+	env []string
+}
+
+// prepare clones the repo if it doesn't exist in the working directory.
+func (g gitRunner) prepare(remote string) error {
+	if _, err := os.Stat(filepath.Join(g.dir, ".git")); err == nil {
+		_, err = g.run("remote", "set-url", "origin", remote)
+
+		return err
+	}
+
+	if err := os.RemoveAll(g.dir); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(g.dir), 0755); err != nil {
+		return err
+	}
+
+	parent := g
+	parent.dir = filepath.Dir(g.dir)
+
+	_, err := parent.run("clone", "--", remote, g.dir)
+
+	return err
+}
+
+func (g gitRunner) run(args ...string) (string, error) {
+	// the helpers configured elsewhere are reset by the empty one.
+	cmd := exec.Command("git", append([]string{"-c", "credential.helper=", "-c", "credential.helper=" + credentialHelper}, args...)...)
+	cmd.Dir = g.dir
+	cmd.Env = append(append(os.Environ(), "GIT_TERMINAL_PROMPT=0"), g.env...)
+
+	b, err := cmd.CombinedOutput()
+	out := string(b)
+	if g.secret != "" {
+		out = strings.ReplaceAll(out, g.secret, "******")
+	}
+
+	if err != nil {
+		return out, fmt.Errorf("git %s: %s, %s", args[0], err.Error(), out)
+	}
+
+	return out, nil
+}
+
+// requestCherryPick queues the cherry-pick of PR at once if it is merged,
+// otherwise the request will be handled when it is merged.
+func (bot *robot) requestCherryPick(
+	e *sdk.NoteEvent, ne giteeclient.PRNoteEvent, branch string, cfg *botConfig, log *logrus.Entry,
+) error {
+	pr := ne.GetPRInfo()
+
+	if err := checkBranchName(branch); err != nil {
+		return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, cfg.message(
+			commentCherryPickInvalidBranch, msgData{"Target": branch},
+		))
+	}
+
+	if hook := e.PullRequest; hook != nil && hook.State == "merged" {
+		return bot.cherryPick(pr, hook.Title, hook.HtmlUrl, []string{branch}, cfg, log)
+	}
+
+	return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, cfg.message(
+		commentCherryPickScheduled,
+		msgData{"Commenter": ne.GetCommenter(), "Branches": branch},
+	))
+}
+
+// handleMergedCherryPick handles the /cherry-pick commands commented
+// before the PR is merged.
+func (bot *robot) handleMergedCherryPick(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	hook := e.GetPullRequest()
+	if bot.picker == nil || hook == nil || hook.State != "merged" ||
+		giteeclient.GetPullRequestAction(e) == giteeclient.PRActionUpdatedLabel {
+		return nil
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+
+	comments, err := bot.cli.ListPRComments(pr.Org, pr.Repo, pr.Number)
+	if err != nil {
+		return err
+	}
+
+	branches := sets.NewString()
+	checked := map[string]bool{}
+
+	for i := range comments {
+		c := &comments[i]
+		if c.User == nil {
+			continue
+		}
+
+		v := parseCherryPickBranches(c.Body)
+		if len(v) == 0 {
+			continue
+		}
+
+		login := c.User.Login
+		if _, ok := checked[login]; !ok {
+			b, err := bot.hasCommandPermission(permissionAuthorOrCollaborator, login, pr, log)
+			if err != nil {
+				log.WithError(err).Errorf("check the permission of cherry-pick for %s", login)
+			}
+
+			checked[login] = b
+		}
+
+		if checked[login] {
+			branches.Insert(v...)
+		}
+	}
+
+	if branches.Len() == 0 {
+		return nil
+	}
+
+	return bot.cherryPick(pr, hook.Title, hook.HtmlUrl, branches.List(), cfg, log)
+}
+
+func (bot *robot) cherryPick(
+	pr giteeclient.PRInfo, title, htmlURL string, branches []string, cfg *botConfig, log *logrus.Entry,
+) error {
+	login, err := bot.getBotLogin()
+	if err != nil {
+		return err
+	}
+
+	commits, err := bot.cli.GetPRCommits(pr.Org, pr.Repo, pr.Number)
+	if err != nil {
+		return err
+	}
+
+	shas := make([]string, 0, len(commits))
+	for i := range commits {
+		shas = append(shas, commits[i].Sha)
+	}
+
+	merr := utils.NewMultiErrors()
+
+	for _, target := range branches {
+		if err := checkBranchName(target); err != nil {
+			err = bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, cfg.message(
+				commentCherryPickInvalidBranch, msgData{"Target": target},
+			))
+			if err != nil {
+				merr.AddError(err)
+			}
+
+			continue
+		}
+
+		req := cherryPickRequest{
+			org:     pr.Org,
+			repo:    pr.Repo,
+			number:  pr.Number,
+			target:  target,
+			branch:  fmt.Sprintf("cherry-pick-%d-to-%s", pr.Number, target),
+			commits: shas,
+		}
+
+		err := bot.picker.enqueue(func() {
+			if err := bot.cherryPickTo(req, login, title, htmlURL, cfg, log); err != nil {
+				log.WithError(err).Errorf("cherry-pick pr: %d to %s", req.number, req.target)
+			}
+		})
+		if err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+func (bot *robot) cherryPickTo(
+	req cherryPickRequest, login, title, htmlURL string, cfg *botConfig, log *logrus.Entry,
+) error {
+	// the PR has been created if the commands are handled again.
+	prs, err := bot.cli.GetPullRequests(
+		req.org, req.repo, giteeclient.ListPullRequestOpt{State: "all", Head: req.branch},
+	)
+	if err != nil {
+		return err
+	}
+
+	if len(prs) > 0 {
+		return nil
+	}
+
+	comment := func(id string, data msgData) error {
+		data["Target"] = req.target
+
+		return bot.cli.CreatePRComment(req.org, req.repo, req.number, cfg.message(id, data))
+	}
+
+	files, err := bot.picker.pick(req, login)
+	if err != nil {
+		if errors.Is(err, errCherryPickConflict) {
+			return comment(commentCherryPickConflict, msgData{"Files": strings.Join(files, ", ")})
+		}
+
+		if err1 := comment(commentCherryPickFailed, msgData{}); err1 != nil {
+			log.WithError(err1).Error("comment the failure of cherry-pick")
+		}
+
+		return err
+	}
+
+	v, err := bot.cli.CreatePullRequest(
+		req.org, req.repo,
+		fmt.Sprintf("[%s] %s", req.target, title),
+		cfg.message(msgCherryPickBody, msgData{"Number": req.number, "URL": htmlURL, "Target": req.target}),
+		req.branch, req.target, true,
+	)
+	if err != nil {
+		return err
+	}
+
+	return comment(commentCherryPickCreated, msgData{"URL": v.HtmlUrl})
+}
+
+func parseCherryPickBranches(comment string) []string {
+	var r []string
+
+	for _, c := range parseCommands(comment) {
+		if args, ok := c.match("cherry-pick"); ok && len(args) == 1 {
+			r = append(r, args[0])
+		}
+	}
+
+	return r
+}
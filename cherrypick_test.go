@@ -0,0 +1,165 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckBranchName(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	cases := map[string]bool{
+		"master":              true,
+		"openEuler-22.03-LTS": true,
+		"release/1.0":         true,
+		"":                    false,
+		"-f":                  false,
+		"--upload-pack=touch": false,
+		"a..b":                false,
+		"a b":                 false,
+		"@{-1}":               false,
+		"refs/heads/x.lock":   false,
+	}
+
+	for name, valid := range cases {
+		if err := checkBranchName(name); (err == nil) != valid {
+			t.Errorf("%q: err = %v, want valid: %v", name, err, valid)
+		}
+	}
+}
+
+// testGit runs git in dir and fails the test on error.
+func testGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@test"}, args...)...)
+	cmd.Dir = dir
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v, %s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func testCommit(t *testing.T, dir, file, content string) string {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testGit(t, dir, "add", file)
+	testGit(t, dir, "commit", "-m", "change "+file)
+
+	return testGit(t, dir, "rev-parse", "HEAD")
+}
+
+func TestCherryPickerPick(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	remote := filepath.Join(root, "remote", "org", "repo.git")
+	work := filepath.Join(root, "work")
+
+	if err := os.MkdirAll(remote, 0755); err != nil {
+		t.Fatal(err)
+	}
+	testGit(t, remote, "init", "--bare")
+
+	testGit(t, root, "clone", remote, work)
+	testCommit(t, work, "a.txt", "a\n")
+	testGit(t, work, "push", "origin", "HEAD:refs/heads/master", "HEAD:refs/heads/stable")
+
+	// the PR changes a.txt and adds b.txt on master.
+	testCommit(t, work, "a.txt", "a\nfrom pr\n")
+	sha := testCommit(t, work, "b.txt", "b\n")
+	testGit(t, work, "push", "origin", "HEAD:refs/heads/master", "HEAD:refs/pull/1/head")
+
+	// the conflicting change on another branch.
+	testGit(t, work, "checkout", "-b", "old", "HEAD~2")
+	testCommit(t, work, "a.txt", "a\nfrom old\n")
+	testGit(t, work, "push", "origin", "HEAD:refs/heads/old")
+
+	c := newCherryPicker(cherryPickOptions{
+		workDir:   filepath.Join(root, "picker"),
+		remoteURL: filepath.Join(root, "remote", "%s", "%s.git"),
+		userName:  "bot",
+		userEmail: "bot@test",
+	}, func() []byte { return []byte("secret") })
+
+	req := cherryPickRequest{
+		org:     "org",
+		repo:    "repo",
+		number:  1,
+		target:  "stable",
+		branch:  "cherry-pick-1-to-stable",
+		commits: []string{testGit(t, work, "rev-parse", sha+"~1"), sha},
+	}
+
+	if _, err := c.pick(req, "bot"); err != nil {
+		t.Fatal(err)
+	}
+
+	if v := testGit(t, remote, "show", req.branch+":b.txt"); v != "b" {
+		t.Errorf("b.txt = %q", v)
+	}
+
+	msg := testGit(t, remote, "log", "-1", "--format=%B", req.branch)
+	if !strings.Contains(msg, "cherry picked from commit "+sha) {
+		t.Errorf("the commit message is %q", msg)
+	}
+
+	// the token is not kept in the local repo.
+	b, err := os.ReadFile(filepath.Join(root, "picker", "org", "repo", ".git", "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "secret") {
+		t.Error("the token is saved in the config of repo")
+	}
+
+	req.target, req.branch = "old", "cherry-pick-1-to-old"
+
+	files, err := c.pick(req, "bot")
+	if !errors.Is(err, errCherryPickConflict) || len(files) != 1 || files[0] != "a.txt" {
+		t.Errorf("files = %v, err = %v", files, err)
+	}
+
+	req.target, req.branch = "--upload-pack=touch", "cherry-pick-1-to-x"
+
+	if _, err := c.pick(req, "bot"); err == nil || errors.Is(err, errCherryPickConflict) {
+		t.Errorf("the invalid branch is picked, err = %v", err)
+	}
+}
+
+func TestCherryPickerEnqueue(t *testing.T) {
+	c := newCherryPicker(cherryPickOptions{}, nil)
+	c.start()
+
+	done := make(chan struct{})
+	if err := c.enqueue(func() { close(done) }); err != nil {
+		t.Fatal(err)
+	}
+
+	c.stop()
+
+	select {
+	case <-done:
+	default:
+		t.Error("the queued task is not run before stopping")
+	}
+
+	if err := c.enqueue(func() {}); err == nil {
+		t.Error("the task is queued after stopping")
+	}
+}
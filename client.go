@@ -0,0 +1,424 @@
+package main
+
+import (
+	"expvar"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+)
+
+var (
+	// the client of gitee wraps the status of response in the error message.
+	regServerError = regexp.MustCompile(`\b5\d\d [A-Z][A-Za-z ]+|(?i)timeout|connection reset|connection refused|\bEOF\b`)
+
+	clientErrorCounts = expvar.NewMap("gitee_client_errors")
+)
+
+type clientOptions struct {
+	maxRetries int
+	baseDelay  time.Duration
+	maxDelay   time.Duration
+	maxWait    time.Duration
+}
+
+func (o *clientOptions) addFlags(fs *flag.FlagSet) {
+	fs.IntVar(&o.maxRetries, "gitee-max-retries", 3, "The number of retry attempts when calling the gitee api failed transiently")
+	fs.DurationVar(&o.baseDelay, "gitee-retry-base-delay", 500*time.Millisecond, "The initial delay of the exponential backoff between retries")
+	fs.DurationVar(&o.maxDelay, "gitee-retry-max-delay", 10*time.Second, "The maximum delay between retries")
+	fs.DurationVar(&o.maxWait, "gitee-retry-max-wait", 30*time.Second, "The maximum total time to wait for the retries of a call, including the ones rejected by the rate limit")
+}
+
+func (o *clientOptions) validate() error {
+	if o.maxRetries < 0 {
+		return fmt.Errorf("gitee-max-retries can't be negative")
+	}
+
+	if o.baseDelay <= 0 || o.maxDelay < o.baseDelay {
+		return fmt.Errorf("invalid retry delay, base: %s, max: %s", o.baseDelay, o.maxDelay)
+	}
+
+	if o.maxWait < 0 {
+		return fmt.Errorf("gitee-retry-max-wait can't be negative")
+	}
+
+	return nil
+}
+
+// retryClient is a decorator of iClient which retries the idempotent calls
+// when they failed transiently. The non-idempotent calls, such as creating comment,
+// are not retried here. The requests rejected by the rate limit are retried by
+// rateLimitTransport, because the status and headers of response are not available here.
+type retryClient struct {
+	cli iClient
+	opt clientOptions
+}
+
+func newRetryClient(cli iClient, opt clientOptions) *retryClient {
+	return &retryClient{cli: cli, opt: opt}
+}
+
+func (c *retryClient) do(method string, idempotent bool, f func() error) error {
+	var (
+		err    error
+		waited time.Duration
+	)
+
+	for i := 0; ; i++ {
+		if err = f(); err == nil {
+			return nil
+		}
+
+		clientErrorCounts.Add(method, 1)
+
+		if i >= c.opt.maxRetries || !idempotent || !regServerError.MatchString(err.Error()) {
+			return err
+		}
+
+		delay := c.backoff(i)
+		if waited+delay > c.opt.maxWait {
+			return err
+		}
+
+		time.Sleep(delay)
+		waited += delay
+	}
+}
+
+// backoff returns the exponential delay with jitter for the n-th retry.
+func (c *retryClient) backoff(n int) time.Duration {
+	d := c.opt.baseDelay << uint(n)
+	if d <= 0 || d > c.opt.maxDelay {
+		d = c.opt.maxDelay
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// rateLimitTransport retries the requests rejected by the rate limit after the time
+// told by the response, which is safe for any request because it was not performed.
+// It gives up and returns the response at once if the total wait would exceed maxWait.
+type rateLimitTransport struct {
+	base    http.RoundTripper
+	maxWait time.Duration
+
+	// defaultDelay is used when the response doesn't tell when to retry.
+	defaultDelay time.Duration
+}
+
+// newGiteeClient creates the client of gitee which sends the requests by hc.
+// giteeclient.NewClient can't be given a http client, but the oauth2 client it creates
+// is based on the transport of http.DefaultClient at that time, so it is replaced meanwhile
+// and the other clients keep using the default one.
+func newGiteeClient(getToken func() []byte, hc *http.Client) giteeclient.Client {
+	v := http.DefaultClient
+	http.DefaultClient = hc
+
+	defer func() {
+		http.DefaultClient = v
+	}()
+
+	return giteeclient.NewClient(getToken)
+}
+
+func newRateLimitTransport(base http.RoundTripper, opt clientOptions) *rateLimitTransport {
+	return &rateLimitTransport{
+		base:         base,
+		maxWait:      opt.maxWait,
+		defaultDelay: opt.maxDelay,
+	}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var waited time.Duration
+
+	for {
+		resp, err := t.base.RoundTrip(req)
+		if err != nil || !isRateLimited(resp) {
+			return resp, err
+		}
+
+		clientErrorCounts.Add("RateLimited", 1)
+
+		delay := retryAfter(resp.Header, time.Now(), t.defaultDelay)
+		if waited+delay > t.maxWait || (req.Body != nil && req.GetBody == nil) {
+			return resp, nil
+		}
+
+		_, _ = io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+
+		waited += delay
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+func isRateLimited(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0")
+}
+
+// retryAfter returns the delay told by the Retry-After header, which is either
+// seconds or a http date, or by the X-RateLimit-Reset header in unix seconds.
+func retryAfter(h http.Header, now time.Time, defaultDelay time.Duration) time.Duration {
+	if v := h.Get("Retry-After"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			return nonNegative(time.Duration(n) * time.Second)
+		}
+
+		if t, err := http.ParseTime(v); err == nil {
+			return nonNegative(t.Sub(now))
+		}
+	}
+
+	if v := h.Get("X-RateLimit-Reset"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return nonNegative(time.Unix(n, 0).Sub(now))
+		}
+	}
+
+	return defaultDelay
+}
+
+func nonNegative(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
+
+func (c *retryClient) AddPRLabel(org, repo string, number int32, label string) error {
+	return c.do("AddPRLabel", true, func() error {
+		return c.cli.AddPRLabel(org, repo, number, label)
+	})
+}
+
+func (c *retryClient) RemovePRLabel(org, repo string, number int32, label string) error {
+	return c.do("RemovePRLabel", true, func() error {
+		return c.cli.RemovePRLabel(org, repo, number, label)
+	})
+}
+
+func (c *retryClient) RemovePRLabels(org, repo string, number int32, label []string) error {
+	return c.do("RemovePRLabels", true, func() error {
+		return c.cli.RemovePRLabels(org, repo, number, label)
+	})
+}
+
+func (c *retryClient) CreatePRComment(org, repo string, number int32, comment string) error {
+	return c.do("CreatePRComment", false, func() error {
+		return c.cli.CreatePRComment(org, repo, number, comment)
+	})
+}
+
+func (c *retryClient) GetUserPermissionsOfRepo(org, repo, login string) (sdk.ProjectMemberPermission, error) {
+	var r sdk.ProjectMemberPermission
+
+	err := c.do("GetUserPermissionsOfRepo", true, func() (err error) {
+		r, err = c.cli.GetUserPermissionsOfRepo(org, repo, login)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) GetPathContent(org, repo, path, ref string) (sdk.Content, error) {
+	var r sdk.Content
+
+	err := c.do("GetPathContent", true, func() (err error) {
+		r, err = c.cli.GetPathContent(org, repo, path, ref)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) CreateRepoLabel(org, repo, label, color string) error {
+	return c.do("CreateRepoLabel", false, func() error {
+		return c.cli.CreateRepoLabel(org, repo, label, color)
+	})
+}
+
+func (c *retryClient) GetRepoLabels(owner, repo string) ([]sdk.Label, error) {
+	var r []sdk.Label
+
+	err := c.do("GetRepoLabels", true, func() (err error) {
+		r, err = c.cli.GetRepoLabels(owner, repo)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) MergePR(owner, repo string, number int32, opt sdk.PullRequestMergePutParam) error {
+	// it is not retried, because the PR may have been merged by the failed call,
+	// and merging it again fails. The reconciler will merge it later if not.
+	return c.do("MergePR", false, func() error {
+		return c.cli.MergePR(owner, repo, number, opt)
+	})
+}
+
+func (c *retryClient) UpdatePullRequest(org, repo string, number int32, param sdk.PullRequestUpdateParam) (sdk.PullRequest, error) {
+	var r sdk.PullRequest
+
+	err := c.do("UpdatePullRequest", true, func() (err error) {
+		r, err = c.cli.UpdatePullRequest(org, repo, number, param)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) GetPullRequests(org, repo string, opts giteeclient.ListPullRequestOpt) ([]sdk.PullRequest, error) {
+	var r []sdk.PullRequest
+
+	err := c.do("GetPullRequests", true, func() (err error) {
+		r, err = c.cli.GetPullRequests(org, repo, opts)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) GetRepos(org string) ([]sdk.Project, error) {
+	var r []sdk.Project
+
+	err := c.do("GetRepos", true, func() (err error) {
+		r, err = c.cli.GetRepos(org)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) ClosePR(org, repo string, number int32) error {
+	return c.do("ClosePR", true, func() error {
+		return c.cli.ClosePR(org, repo, number)
+	})
+}
+
+func (c *retryClient) GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error) {
+	var r sdk.PullRequest
+
+	err := c.do("GetGiteePullRequest", true, func() (err error) {
+		r, err = c.cli.GetGiteePullRequest(org, repo, number)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) ListPRComments(org, repo string, number int32) ([]sdk.PullRequestComments, error) {
+	var r []sdk.PullRequestComments
+
+	err := c.do("ListPRComments", true, func() (err error) {
+		r, err = c.cli.ListPRComments(org, repo, number)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) UpdatePRComment(org, repo string, commentID int32, comment string) error {
+	return c.do("UpdatePRComment", true, func() error {
+		return c.cli.UpdatePRComment(org, repo, commentID, comment)
+	})
+}
+
+func (c *retryClient) GetBot() (sdk.User, error) {
+	var r sdk.User
+
+	err := c.do("GetBot", true, func() (err error) {
+		r, err = c.cli.GetBot()
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) GetPRCommits(org, repo string, number int32) ([]sdk.PullRequestCommits, error) {
+	var r []sdk.PullRequestCommits
+
+	err := c.do("GetPRCommits", true, func() (err error) {
+		r, err = c.cli.GetPRCommits(org, repo, number)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) GetIssue(org, repo, number string) (sdk.Issue, error) {
+	var r sdk.Issue
+
+	err := c.do("GetIssue", true, func() (err error) {
+		r, err = c.cli.GetIssue(org, repo, number)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) CreateIssueComment(org, repo string, number string, comment string) error {
+	return c.do("CreateIssueComment", false, func() error {
+		return c.cli.CreateIssueComment(org, repo, number, comment)
+	})
+}
+
+func (c *retryClient) UpdateIssue(owner, number string, param sdk.IssueUpdateParam) (sdk.Issue, error) {
+	var r sdk.Issue
+
+	err := c.do("UpdateIssue", true, func() (err error) {
+		r, err = c.cli.UpdateIssue(owner, number, param)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error) {
+	var r []sdk.PullRequestFiles
+
+	err := c.do("GetPullRequestChanges", true, func() (err error) {
+		r, err = c.cli.GetPullRequestChanges(org, repo, number)
+		return
+	})
+
+	return r, err
+}
+
+func (c *retryClient) CreatePullRequest(
+	org, repo, title, body, head, base string, canModify bool,
+) (sdk.PullRequest, error) {
+	var r sdk.PullRequest
+
+	err := c.do("CreatePullRequest", false, func() (err error) {
+		r, err = c.cli.CreatePullRequest(org, repo, title, body, head, base, canModify)
+		return
+	})
+
+	return r, err
+}
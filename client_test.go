@@ -0,0 +1,166 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRetryClientDo(t *testing.T) {
+	c := newRetryClient(nil, clientOptions{
+		maxRetries: 2,
+		baseDelay:  time.Millisecond,
+		maxDelay:   time.Millisecond,
+		maxWait:    time.Second,
+	})
+
+	cases := []struct {
+		name       string
+		idempotent bool
+		err        error
+		calls      int
+	}{
+		{name: "success", idempotent: true, calls: 1},
+		{name: "server error", idempotent: true, err: errors.New("err: 502 Bad Gateway"), calls: 3},
+		{name: "timeout", idempotent: true, err: errors.New("i/o timeout"), calls: 3},
+		{name: "not idempotent", err: errors.New("err: 502 Bad Gateway"), calls: 1},
+		{name: "client error", idempotent: true, err: errors.New("err: 404 Not Found"), calls: 1},
+	}
+
+	for _, tc := range cases {
+		calls := 0
+
+		err := c.do("test", tc.idempotent, func() error {
+			calls++
+			return tc.err
+		})
+
+		if err != tc.err || calls != tc.calls {
+			t.Errorf("%s: err = %v, calls = %d, want %v, %d", tc.name, err, calls, tc.err, tc.calls)
+		}
+	}
+}
+
+func TestRetryClientDoMaxWait(t *testing.T) {
+	c := newRetryClient(nil, clientOptions{
+		maxRetries: 10,
+		baseDelay:  20 * time.Millisecond,
+		maxDelay:   20 * time.Millisecond,
+		maxWait:    20 * time.Millisecond,
+	})
+
+	calls := 0
+	_ = c.do("test", true, func() error {
+		calls++
+		return errors.New("502 Bad Gateway")
+	})
+
+	if calls != 2 {
+		t.Errorf("calls = %d, want 2", calls)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+	}{
+		{name: "no header", want: time.Minute},
+		{name: "seconds", header: map[string]string{"Retry-After": "3"}, want: 3 * time.Second},
+		{
+			name:   "http date",
+			header: map[string]string{"Retry-After": now.Add(5 * time.Second).Format(http.TimeFormat)},
+			want:   5 * time.Second,
+		},
+		{
+			name:   "reset",
+			header: map[string]string{"X-RateLimit-Reset": "1622505610"},
+			want:   10 * time.Second,
+		},
+		{
+			name:   "reset passed",
+			header: map[string]string{"X-RateLimit-Reset": "1622505500"},
+			want:   0,
+		},
+		{name: "invalid", header: map[string]string{"Retry-After": "soon"}, want: time.Minute},
+	}
+
+	for _, tc := range cases {
+		h := http.Header{}
+		for k, v := range tc.header {
+			h.Set(k, v)
+		}
+
+		if v := retryAfter(h, now, time.Minute); v != tc.want {
+			t.Errorf("%s: retryAfter = %s, want %s", tc.name, v, tc.want)
+		}
+	}
+}
+
+func TestRateLimitTransport(t *testing.T) {
+	cases := []struct {
+		name       string
+		retryAfter string
+		limited    int
+		status     int
+		calls      int
+	}{
+		{name: "retried", retryAfter: "0", limited: 2, status: http.StatusOK, calls: 3},
+		{name: "wait too long", retryAfter: "60", limited: 1, status: http.StatusTooManyRequests, calls: 1},
+	}
+
+	for _, tc := range cases {
+		calls := 0
+
+		s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+
+			if b := make([]byte, 4); r.ContentLength != 4 || r.Body == nil {
+				t.Errorf("%s: the body is not resent", tc.name)
+			} else if _, _ = r.Body.Read(b); string(b) != "body" {
+				t.Errorf("%s: body = %q", tc.name, b)
+			}
+
+			if calls <= tc.limited {
+				w.Header().Set("Retry-After", tc.retryAfter)
+				w.WriteHeader(http.StatusTooManyRequests)
+			}
+		}))
+
+		cli := http.Client{Transport: newRateLimitTransport(http.DefaultTransport, clientOptions{
+			maxDelay: time.Millisecond,
+			maxWait:  time.Second,
+		})}
+
+		resp, err := cli.Post(s.URL, "text/plain", strings.NewReader("body"))
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.status || calls != tc.calls {
+			t.Errorf(
+				"%s: status = %d, calls = %d, want %d, %d",
+				tc.name, resp.StatusCode, calls, tc.status, tc.calls,
+			)
+		}
+
+		s.Close()
+	}
+}
+
+func TestNewGiteeClientKeepsDefaultClient(t *testing.T) {
+	v := http.DefaultClient
+
+	newGiteeClient(func() []byte { return []byte("token") }, &http.Client{})
+
+	if http.DefaultClient != v {
+		t.Error("the default http client is not restored")
+	}
+}
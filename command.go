@@ -0,0 +1,416 @@
+package main
+
+import (
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// command is a slash command parsed from a line of comment, such as /lgtm cancel @alice.
+// The tokens are the fields of the line without the leading slash.
+type command struct {
+	tokens []string
+}
+
+// match returns the arguments following the name of command, which may be
+// composed of several words, such as "lgtm cancel".
+func (c command) match(name string) ([]string, bool) {
+	words := strings.Fields(name)
+	if len(c.tokens) < len(words) {
+		return nil, false
+	}
+
+	for i, w := range words {
+		if !strings.EqualFold(c.tokens[i], w) {
+			return nil, false
+		}
+	}
+
+	return c.tokens[len(words):], true
+}
+
+// parseCommands returns the commands in the comment, each of which is a line
+// starting with slash. The lines in the code blocks or the quotes are ignored.
+func parseCommands(comment string) []command {
+	var r []command
+
+	seen := map[string]bool{}
+	inCodeBlock := false
+
+	for _, line := range strings.Split(comment, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+
+		if v := strings.TrimSpace(line); strings.HasPrefix(v, "```") || strings.HasPrefix(v, "~~~") {
+			inCodeBlock = !inCodeBlock
+
+			continue
+		}
+
+		// the quotes start with > and the indented code blocks start with spaces,
+		// so only the lines starting with slash are the commands.
+		if inCodeBlock || !strings.HasPrefix(line, "/") {
+			continue
+		}
+
+		tokens := strings.Fields(line[1:])
+		if len(tokens) == 0 {
+			continue
+		}
+
+		if k := strings.ToLower(strings.Join(tokens, " ")); !seen[k] {
+			seen[k] = true
+			r = append(r, command{tokens: tokens})
+		}
+	}
+
+	return r
+}
+
+type commandPermission int
+
+// the zero value means the permission is not set.
+const (
+	permissionAnyone commandPermission = iota + 1
+	permissionAuthorOrCollaborator
+	permissionCollaborator
+)
+
+// commandContext is the context of handling a command.
+type commandContext struct {
+	event giteeclient.PRNoteEvent
+	note  *sdk.NoteEvent
+	cfg   *botConfig
+	log   *logrus.Entry
+	args  []string
+}
+
+type commandSpec struct {
+	// name is the name of command without slash, such as "lgtm cancel".
+	name string
+
+	// minArgs and maxArgs limit the number of arguments. The command with
+	// invalid arguments is ignored.
+	minArgs int
+	maxArgs int
+
+	// usage describes the arguments in the reply of /help, such as [@user].
+	usage string
+
+	permission commandPermission
+
+	// argsPermission is the permission needed when the arguments are given, such as
+	// /lgtm cancel @user. It is the same as permission if not set.
+	argsPermission commandPermission
+
+	// precheck runs before the permission is checked and returns the reply to refuse the command,
+	// such as the self lgtm, so that the commenter is told the real reason. It is optional.
+	precheck func(bot *robot, c *commandContext) (string, error)
+
+	// denied returns the reply to the commenter who has no permission.
+	// A general reply is used if it is nil.
+	denied func(cfg *botConfig, commenter string) string
+
+	// allowMerged specifies that the command can be used on the merged PR too.
+	allowMerged bool
+
+	// enabled reports whether the command is enabled for the repo. It is always enabled if nil.
+	enabled func(bot *robot, cfg *botConfig) bool
+
+	handler func(bot *robot, c *commandContext) error
+}
+
+func (s *commandSpec) isEnabled(bot *robot, cfg *botConfig) bool {
+	return s.enabled == nil || s.enabled(bot, cfg)
+}
+
+func (s *commandSpec) deniedReply(cfg *botConfig, commenter string) string {
+	if s.denied != nil {
+		return s.denied(cfg, commenter)
+	}
+
+	return cfg.message(commentNoPermissionForCommand, msgData{"Commenter": commenter, "Command": s.name})
+}
+
+func (s *commandSpec) permissionFor(args []string) commandPermission {
+	if len(args) > 0 && s.argsPermission != 0 {
+		return s.argsPermission
+	}
+
+	return s.permission
+}
+
+// commandRegistry holds the commands in the order of registration.
+type commandRegistry struct {
+	specs []commandSpec
+}
+
+func (r *commandRegistry) register(s commandSpec) {
+	r.specs = append(r.specs, s)
+}
+
+// find returns the command whose name matches the most words of c.
+func (r *commandRegistry) find(c command) (*commandSpec, []string) {
+	var (
+		spec *commandSpec
+		args []string
+		n    int
+	)
+
+	for i := range r.specs {
+		s := &r.specs[i]
+
+		v, ok := c.match(s.name)
+		if !ok {
+			continue
+		}
+
+		if k := len(c.tokens) - len(v); k > n {
+			spec, args, n = s, v, k
+		}
+	}
+
+	if spec == nil || len(args) < spec.minArgs || len(args) > spec.maxArgs {
+		return nil, nil
+	}
+
+	return spec, args
+}
+
+func newCommandRegistry() *commandRegistry {
+	r := new(commandRegistry)
+
+	r.register(commandSpec{
+		name:       "lgtm",
+		permission: permissionCollaborator,
+		denied: func(cfg *botConfig, commenter string) string {
+			return cfg.message(commentNoPermissionForLgtmLabel, msgData{"Commenter": commenter})
+		},
+		precheck: func(bot *robot, c *commandContext) (string, error) {
+			return bot.checkSelfLGTM(c.cfg, c.event.GetPRInfo(), c.event.GetCommenter(), getCommenterEmail(c.note))
+		},
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.addLGTM(c.cfg, c.event, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:           "lgtm cancel",
+		maxArgs:        1,
+		usage:          "@user",
+		permission:     permissionAuthorOrCollaborator,
+		argsPermission: permissionCollaborator,
+		denied:         noPermissionForLabel("remove", lgtmLabel),
+		handler: func(bot *robot, c *commandContext) error {
+			if len(c.args) == 0 {
+				return bot.removeLGTM(c.cfg, c.event, c.log)
+			}
+
+			return bot.revokeLGTM(c.cfg, c.event, strings.TrimPrefix(c.args[0], "@"), c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "approve",
+		permission: permissionCollaborator,
+		denied:     noPermissionForLabel("add", approvedLabel),
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.AddApprove(c.cfg, c.event, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "approve cancel",
+		permission: permissionCollaborator,
+		denied:     noPermissionForLabel("remove", approvedLabel),
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.removeApprove(c.cfg, c.event, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "check-pr",
+		permission: permissionAnyone,
+		handler: func(bot *robot, c *commandContext) error {
+			if _, err := bot.syncDCOLabel(c.event.GetPRInfo(), c.cfg, c.log); err != nil {
+				c.log.WithError(err).Error("recheck dco")
+			}
+
+			return bot.tryMerge(c.event, c.cfg, true, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "lifecycle frozen",
+		permission: permissionCollaborator,
+		denied:     noPermissionForLabel("add", frozenLabel),
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.freezeLifecycle(c.event, c.cfg, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "remove-lifecycle frozen",
+		permission: permissionCollaborator,
+		denied:     noPermissionForLabel("remove", frozenLabel),
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.unfreezeLifecycle(c.event, c.cfg)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "remove-lifecycle stale",
+		permission: permissionAnyone,
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.removeStale(c.event, c.cfg)
+		},
+	})
+
+	r.register(commandSpec{
+		name:       "ok-to-test",
+		permission: permissionCollaborator,
+		denied:     noPermissionForLabel("add", okToTestLabel),
+		enabled: func(bot *robot, cfg *botConfig) bool {
+			return cfg.CITrigger.RequireOkToTest
+		},
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.okToTest(c.event, c.cfg, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:        "cherry-pick",
+		minArgs:     1,
+		maxArgs:     1,
+		usage:       "branch",
+		permission:  permissionAuthorOrCollaborator,
+		allowMerged: true,
+		denied: func(cfg *botConfig, commenter string) string {
+			return cfg.message(commentNoPermissionForCherryPick, msgData{"Commenter": commenter})
+		},
+		enabled: func(bot *robot, cfg *botConfig) bool {
+			return bot.picker != nil
+		},
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.requestCherryPick(c.note, c.event, c.args[0], c.cfg, c.log)
+		},
+	})
+
+	r.register(commandSpec{
+		name:        "help",
+		permission:  permissionAnyone,
+		allowMerged: true,
+		handler: func(bot *robot, c *commandContext) error {
+			return bot.replyHelp(c)
+		},
+	})
+
+	return r
+}
+
+func noPermissionForLabel(action, label string) func(*botConfig, string) string {
+	return func(cfg *botConfig, commenter string) string {
+		return cfg.message(
+			commentNoPermissionForLabel,
+			msgData{"Commenter": commenter, "Action": action, "Label": label},
+		)
+	}
+}
+
+// handleCommands dispatches the commands in the comment of PR to their handlers in order.
+func (bot *robot) handleCommands(e *sdk.NoteEvent, cfg *botConfig, log *logrus.Entry) error {
+	ne := giteeclient.NewPRNoteEvent(e)
+
+	if !ne.IsPullRequest() || !ne.IsCreatingCommentEvent() {
+		return nil
+	}
+
+	isMerged := e.PullRequest != nil && e.PullRequest.State == "merged"
+
+	merr := utils.NewMultiErrors()
+
+	for _, c := range parseCommands(ne.GetComment()) {
+		spec, args := bot.commands.find(c)
+		if spec == nil || !spec.isEnabled(bot, cfg) {
+			continue
+		}
+
+		if !ne.IsPROpen() && !(spec.allowMerged && isMerged) {
+			continue
+		}
+
+		ctx := &commandContext{
+			event: ne,
+			note:  e,
+			cfg:   cfg,
+			log:   log.WithField("command", spec.name),
+			args:  args,
+		}
+
+		if err := bot.runCommand(spec, ctx); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+func (bot *robot) runCommand(spec *commandSpec, c *commandContext) error {
+	commenter := c.event.GetCommenter()
+	pr := c.event.GetPRInfo()
+
+	if spec.precheck != nil {
+		reply, err := spec.precheck(bot, c)
+		if err != nil {
+			return err
+		}
+
+		if reply != "" {
+			return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, reply)
+		}
+	}
+
+	v, err := bot.hasCommandPermission(spec.permissionFor(c.args), commenter, pr, c.log)
+	if err != nil {
+		return err
+	}
+
+	if !v {
+		return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, spec.deniedReply(c.cfg, commenter))
+	}
+
+	return spec.handler(bot, c)
+}
+
+func (bot *robot) hasCommandPermission(
+	p commandPermission, login string, pr giteeclient.PRInfo, log *logrus.Entry,
+) (bool, error) {
+	switch p {
+	case permissionAnyone:
+		return true, nil
+
+	case permissionAuthorOrCollaborator:
+		if strings.EqualFold(login, pr.Author) {
+			return true, nil
+		}
+
+		return bot.hasPermission(login, pr, log)
+
+	case permissionCollaborator:
+		return bot.hasPermission(login, pr, log)
+	}
+
+	// the permission of command is not set, which is never taken as granted.
+	return false, nil
+}
+
+// getCommenterEmail returns the public email of commenter which may be empty.
+func getCommenterEmail(e *sdk.NoteEvent) string {
+	if c := e.GetComment(); c != nil && c.User != nil {
+		return c.User.Email
+	}
+
+	return ""
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+)
+
+func TestParseCommands(t *testing.T) {
+	cases := []struct {
+		name    string
+		comment string
+		want    []string
+	}{
+		{name: "single", comment: "/lgtm", want: []string{"lgtm"}},
+		{name: "args", comment: "/lgtm cancel  @alice \r\n", want: []string{"lgtm cancel @alice"}},
+		{name: "multiple", comment: "looks good\n/lgtm\n/approve", want: []string{"lgtm", "approve"}},
+		{name: "duplicate", comment: "/lgtm\n/LGTM\n/lgtm", want: []string{"lgtm"}},
+		{name: "quoted", comment: "> /lgtm\n/approve", want: []string{"approve"}},
+		{name: "indented", comment: "    /lgtm"},
+		{name: "inline", comment: "please /lgtm"},
+		{name: "code block", comment: "```\n/lgtm\n```\n/approve", want: []string{"approve"}},
+		{name: "tilde code block", comment: "~~~sh\n/lgtm\n~~~", want: nil},
+		{name: "slash only", comment: "/\n/ "},
+	}
+
+	for _, tc := range cases {
+		cmds := parseCommands(tc.comment)
+
+		var got []string
+		for _, c := range cmds {
+			got = append(got, strings.Join(c.tokens, " "))
+		}
+
+		if strings.Join(got, "|") != strings.Join(tc.want, "|") {
+			t.Errorf("%s: commands = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestCommandRegistryFind(t *testing.T) {
+	r := newCommandRegistry()
+
+	cases := []struct {
+		line string
+		name string
+		args []string
+	}{
+		{line: "/lgtm", name: "lgtm"},
+		{line: "/LGTM", name: "lgtm"},
+		{line: "/lgtm cancel", name: "lgtm cancel"},
+		{line: "/Lgtm Cancel @alice", name: "lgtm cancel", args: []string{"@alice"}},
+		// too many arguments.
+		{line: "/lgtm cancel @alice @bob"},
+		{line: "/lgtm please"},
+		{line: "/cherry-pick"},
+		{line: "/cherry-pick stable", name: "cherry-pick", args: []string{"stable"}},
+		{line: "/approve cancel", name: "approve cancel"},
+		{line: "/unknown"},
+	}
+
+	for _, tc := range cases {
+		cmds := parseCommands(tc.line)
+		if len(cmds) != 1 {
+			t.Fatalf("%s: commands = %v", tc.line, cmds)
+		}
+
+		spec, args := r.find(cmds[0])
+
+		name := ""
+		if spec != nil {
+			name = spec.name
+		}
+
+		if name != tc.name || strings.Join(args, " ") != strings.Join(tc.args, " ") {
+			t.Errorf("%s: find = %q %q, want %q %q", tc.line, name, args, tc.name, tc.args)
+		}
+	}
+}
+
+func TestRunCommandPermission(t *testing.T) {
+	cases := []struct {
+		name    string
+		comment string
+		denied  bool
+	}{
+		{name: "check-pr", comment: "/check-pr"},
+		{name: "remove-lifecycle stale", comment: "/remove-lifecycle stale"},
+		{name: "help", comment: "/help"},
+		{name: "collaborator only", comment: "/approve", denied: true},
+	}
+
+	cfg := &botConfig{}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range cases {
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		cmds := parseCommands(tc.comment)
+		spec, args := bot.commands.find(cmds[0])
+		if spec == nil {
+			t.Fatalf("%s: command is not found", tc.name)
+		}
+
+		e := newTestNoteEvent("visitor", tc.comment, staleLabel)
+		c := &commandContext{
+			event: giteeclient.NewPRNoteEvent(e),
+			note:  e,
+			cfg:   cfg,
+			log:   newTestLog(),
+			args:  args,
+		}
+
+		if err := bot.runCommand(spec, c); err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+
+		denied := len(cli.created) > 0 && strings.Contains(cli.created[0], "has no permission")
+		if denied != tc.denied {
+			t.Errorf("%s: denied = %t, want %t, comments: %q", tc.name, denied, tc.denied, cli.created)
+		}
+	}
+}
+
+func TestRunCommandWithoutPermission(t *testing.T) {
+	cfg := &botConfig{}
+	cfg.setDefault()
+
+	for _, p := range []commandPermission{0, permissionCollaborator} {
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		handled := false
+		spec := &commandSpec{
+			name:       "test",
+			permission: p,
+			handler: func(*robot, *commandContext) error {
+				handled = true
+
+				return nil
+			},
+		}
+
+		c := &commandContext{
+			event: giteeclient.NewPRNoteEvent(newTestNoteEvent("visitor", "/test")),
+			cfg:   cfg,
+			log:   newTestLog(),
+		}
+
+		if err := bot.runCommand(spec, c); err != nil {
+			t.Fatalf("%d: %v", p, err)
+		}
+
+		if handled {
+			t.Errorf("%d: the command is handled", p)
+		}
+
+		if len(cli.created) != 1 || !strings.Contains(cli.created[0], "/test") {
+			t.Errorf("%d: comments = %q", p, cli.created)
+		}
+	}
+}
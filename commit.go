@@ -0,0 +1,91 @@
+package main
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+var regCoAuthoredBy = regexp.MustCompile(`(?mi)^co-authored-by:\s*(.+)$`)
+
+// commitIdentity is the identity of a person who contributes to a commit.
+// login is the account of gitee which may be empty if the email is not bound to one.
+type commitIdentity struct {
+	login string
+	name  string
+	email string
+}
+
+// is reports whether the identity belongs to the gitee user of login and email.
+// Only the login bound by gitee or the full email is compared, because the name
+// and the local part of email can be set arbitrarily by anyone.
+// The email of user may be empty if it is not public.
+func (ci commitIdentity) is(login, email string) bool {
+	if login != "" && strings.EqualFold(ci.login, login) {
+		return true
+	}
+
+	return email != "" && strings.EqualFold(ci.email, email)
+}
+
+// getCommitAuthors returns the authors and co-authors of commits, and the committers if withCommitter is true.
+func getCommitAuthors(commits []sdk.PullRequestCommits, withCommitter bool) []commitIdentity {
+	var r []commitIdentity
+
+	for i := range commits {
+		c := &commits[i]
+
+		author := commitIdentity{}
+		if c.Author != nil {
+			author.login = c.Author.Login
+		}
+		if c.Commit != nil && c.Commit.Author != nil {
+			author.name = c.Commit.Author.Name
+			author.email = c.Commit.Author.Email
+		}
+		r = append(r, author)
+
+		if withCommitter {
+			committer := commitIdentity{}
+			if c.Committer != nil {
+				committer.login = c.Committer.Login
+			}
+			if c.Commit != nil && c.Commit.Committer != nil {
+				committer.name = c.Commit.Committer.Name
+				committer.email = c.Commit.Committer.Email
+			}
+			r = append(r, committer)
+		}
+
+		if c.Commit != nil {
+			r = append(r, parseCoAuthors(c.Commit.Message)...)
+		}
+	}
+
+	return r
+}
+
+func parseCoAuthors(message string) []commitIdentity {
+	var r []commitIdentity
+
+	for _, m := range regCoAuthoredBy.FindAllStringSubmatch(message, -1) {
+		if v := parseIdentity(m[1]); v.name != "" || v.email != "" {
+			r = append(r, v)
+		}
+	}
+
+	return r
+}
+
+// parseIdentity parses the identity in the format of 'name <email>'.
+func parseIdentity(s string) commitIdentity {
+	s = strings.TrimSpace(s)
+
+	if a, err := mail.ParseAddress(s); err == nil {
+		return commitIdentity{name: a.Name, email: a.Address}
+	}
+
+	return commitIdentity{name: s}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/sirupsen/logrus"
+)
+
+// checkCommits checks the commits of PR against the commit rules of repo.
+// If the PR will be squashed instead of being blocked, it passes and the
+// merge method is switched to squash.
+func (m *mergeHelper) checkCommits(log *logrus.Entry) mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkCommitRules, nil)}
+
+	rules := &m.cfg.CommitRules
+	if !rules.enabled() {
+		c.Passed = true
+
+		return c
+	}
+
+	commits, err := m.cli.GetPRCommits(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		log.WithError(err).Error("get the commits of pr")
+		c.Detail = m.cfg.message(msgCommitsUnknown, nil)
+
+		return c
+	}
+
+	v := commitRuleViolations(m.cfg, commits)
+	if len(v) == 0 {
+		c.Passed = true
+
+		return c
+	}
+
+	if rules.SquashInstead {
+		m.squash = true
+		c.Passed = true
+		v = append(v, m.cfg.message(msgCommitsSquashed, nil))
+	}
+
+	c.Detail = strings.Join(v, " ")
+
+	return c
+}
+
+func commitRuleViolations(cfg *botConfig, commits []sdk.PullRequestCommits) []string {
+	rules := &cfg.CommitRules
+
+	var r []string
+
+	if n := uint(len(commits)); rules.MaxCommits > 0 && n > rules.MaxCommits {
+		r = append(r, cfg.message(
+			msgTooManyCommits, msgData{"Max": rules.MaxCommits, "Current": n},
+		))
+	}
+
+	var fixups, invalid []string
+
+	for i := range commits {
+		c := &commits[i]
+		if c.Commit == nil {
+			continue
+		}
+
+		title := strings.SplitN(strings.TrimSpace(c.Commit.Message), "\n", 2)[0]
+		item := fmt.Sprintf("`%s`", shortSHA(c.Sha))
+
+		if rules.ForbidFixup && (strings.HasPrefix(title, "fixup!") || strings.HasPrefix(title, "squash!")) {
+			fixups = append(fixups, item)
+		}
+
+		if !rules.isMessageValid(title) {
+			invalid = append(invalid, item)
+		}
+	}
+
+	if len(fixups) > 0 {
+		r = append(r, cfg.message(msgFixupCommits, msgData{"Commits": strings.Join(fixups, ", ")}))
+	}
+
+	if len(invalid) > 0 {
+		r = append(r, cfg.message(msgInvalidCommitMessage, msgData{
+			"Pattern": rules.MessagePattern,
+			"Commits": strings.Join(invalid, ", "),
+		}))
+	}
+
+	return r
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 8 {
+		return sha[:8]
+	}
+
+	return sha
+}
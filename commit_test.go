@@ -0,0 +1,45 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestCommitIdentityIs(t *testing.T) {
+	cases := []struct {
+		name     string
+		identity commitIdentity
+		login    string
+		email    string
+		want     bool
+	}{
+		{name: "login", identity: commitIdentity{login: "John"}, login: "john", want: true},
+		{name: "full email", identity: commitIdentity{email: "john@corp.com"}, email: "John@corp.com", want: true},
+		{name: "name", identity: commitIdentity{name: "john"}, login: "john"},
+		{name: "local part of email", identity: commitIdentity{email: "john@corp.com"}, login: "john"},
+		{name: "other email", identity: commitIdentity{email: "john@corp.com"}, email: "john@mail.com"},
+		{name: "empty", identity: commitIdentity{}},
+	}
+
+	for _, tc := range cases {
+		if v := tc.identity.is(tc.login, tc.email); v != tc.want {
+			t.Errorf("%s: is = %v, want %v", tc.name, v, tc.want)
+		}
+	}
+}
+
+func TestParseCoAuthors(t *testing.T) {
+	msg := "feat: x\n\nCo-authored-by: Jane Doe <jane@corp.com>\nco-authored-by: bob\n"
+
+	v := parseCoAuthors(msg)
+	if len(v) != 2 {
+		t.Fatalf("co-authors = %v", v)
+	}
+
+	if v[0].name != "Jane Doe" || v[0].email != "jane@corp.com" || v[0].login != "" {
+		t.Errorf("co-author = %+v", v[0])
+	}
+
+	if v[1].name != "bob" || v[1].email != "" {
+		t.Errorf("co-author = %+v", v[1])
+	}
+}
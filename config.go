@@ -2,14 +2,32 @@ package main
 
 import (
 	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
 	libconfig "github.com/opensourceways/community-robot-lib/config"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 type pullRequestMergeMethod string
 
+type selfLGTMPolicy string
+
+type selfApprovalPolicy string
+
 const (
 	mergeMethodeMerge pullRequestMergeMethod = "merge"
 	mergeMethodSquash pullRequestMergeMethod = "squash"
+
+	selfLGTMPRAuthor                   selfLGTMPolicy = "pr_author"
+	selfLGTMCommitAuthors              selfLGTMPolicy = "commit_authors"
+	selfLGTMCommitAuthorsAndCommitters selfLGTMPolicy = "commit_authors_and_committers"
+
+	selfApprovalAllow         selfApprovalPolicy = "allow"
+	selfApprovalForbid        selfApprovalPolicy = "forbid"
+	selfApprovalWithExtraLGTM selfApprovalPolicy = "allow_with_extra_lgtm"
 )
 
 type configuration struct {
@@ -85,6 +103,81 @@ type botConfig struct {
 
 	// FreezeFile is the freeze branch of community
 	FreezeFile []freezeFile `json:"freeze_file,omitempty"`
+
+	// Stale specifies how to handle the inactive PRs. It works only when the reconciler is enabled.
+	Stale staleConfig `json:"stale,omitempty"`
+
+	// CITrigger specifies how to trigger the CI when the source branch of PR is changed.
+	CITrigger ciTriggerConfig `json:"ci_trigger,omitempty"`
+
+	// Language specifies the built-in messages of bot. Valid options are en and zh.
+	// The default value is en.
+	Language string `json:"language,omitempty"`
+
+	// Messages overrides the built-in messages. The key is the id of message
+	// and the value is a text/template of golang.
+	Messages map[string]string `json:"messages,omitempty"`
+
+	// SelfLGTMPolicy specifies who is treated as the self-reviewer that can't add lgtm to the PR.
+	// Valid options are pr_author, commit_authors and commit_authors_and_committers.
+	// commit_authors includes the co-authors in the Co-authored-by trailers of commits.
+	// The commit authors are matched by their gitee accounts or the full public emails.
+	// The default value is pr_author.
+	SelfLGTMPolicy selfLGTMPolicy `json:"self_lgtm_policy,omitempty"`
+
+	// SelfApprovalPolicy specifies whether the author of PR can approve it.
+	// Valid options are allow, forbid and allow_with_extra_lgtm. The last one means
+	// the PR approved by its author needs extra lgtm specified by SelfApprovalExtraLgtm
+	// before merging, unless it is approved by others too. It works only when LgtmCountsRequired is greater than 1.
+	// The default value is allow.
+	SelfApprovalPolicy selfApprovalPolicy `json:"self_approval_policy,omitempty"`
+
+	// SelfApprovalExtraLgtm specifies the number of lgtm needed in addition to LgtmCountsRequired
+	// for the PR approved by its author. The default value is 1.
+	SelfApprovalExtraLgtm uint `json:"self_approval_extra_lgtm,omitempty"`
+
+	// ReviewPeriod specifies the minimum time that a PR must stay open before it is merged.
+	ReviewPeriod reviewPeriodConfig `json:"review_period,omitempty"`
+
+	// PRPolicy specifies the rules which the title and description of PR must follow to be merged.
+	PRPolicy prPolicyConfig `json:"pr_policy,omitempty"`
+
+	// LinkedIssues specifies how to handle the issues which the PR claims to close.
+	LinkedIssues linkedIssuesConfig `json:"linked_issues,omitempty"`
+
+	// CheckDCO is a switch used to check whether every commit of PR is signed off by
+	// its author with the Signed-off-by trailer. The PR is labeled with dco-passed or
+	// dco-failed, and it can be merged only when all its commits are signed off.
+	CheckDCO bool `json:"check_dco,omitempty"`
+
+	// CommitRules specifies the rules which the commits of PR must follow to be merged.
+	CommitRules commitRulesConfig `json:"commit_rules,omitempty"`
+
+	// Size specifies how to label the PR with its size computed from the changed lines.
+	Size sizeConfig `json:"size,omitempty"`
+
+	// PathLabels specifies the labels added to the PR which changes the files
+	// matching the path globs. The labels are removed when the files no longer match.
+	PathLabels []pathLabel `json:"path_labels,omitempty"`
+
+	// TrackConflicts is a switch used to add the needs-rebase label and notify the author
+	// when the PR becomes unmergeable, which is detected on the PR events, the pushes
+	// of target branch and by the reconciler. The label is removed once the PR is mergeable again.
+	TrackConflicts bool `json:"track_conflicts,omitempty"`
+
+	// ProtectedPaths specifies the paths whose changes must be approved by
+	// one of the specified approvers, in addition to the approved label.
+	ProtectedPaths []protectedPath `json:"protected_paths,omitempty"`
+
+	// NativeReview specifies how to map the review and test passes of PR on gitee
+	// to the review state of bot.
+	NativeReview nativeReviewConfig `json:"native_review,omitempty"`
+
+	// StatusComment is a switch used to maintain a single status comment of PR
+	// which is edited in place, instead of posting a new comment for each command.
+	StatusComment bool `json:"status_comment,omitempty"`
+
+	templates messageTemplates
 }
 
 func (c *botConfig) setDefault() {
@@ -95,6 +188,26 @@ func (c *botConfig) setDefault() {
 	if c.MergeMethod == "" {
 		c.MergeMethod = mergeMethodeMerge
 	}
+
+	if c.Language == "" {
+		c.Language = languageEnglish
+	}
+
+	if c.SelfLGTMPolicy == "" {
+		c.SelfLGTMPolicy = selfLGTMPRAuthor
+	}
+
+	if c.SelfApprovalPolicy == "" {
+		c.SelfApprovalPolicy = selfApprovalAllow
+	}
+
+	if c.SelfApprovalExtraLgtm == 0 {
+		c.SelfApprovalExtraLgtm = 1
+	}
+
+	c.CITrigger.setDefault()
+	c.LinkedIssues.setDefault()
+	c.Size.setDefault()
 }
 
 func (c *botConfig) validate() error {
@@ -102,13 +215,104 @@ func (c *botConfig) validate() error {
 		return fmt.Errorf("unsupported merge method:%s", m)
 	}
 
+	switch c.SelfLGTMPolicy {
+	case "", selfLGTMPRAuthor, selfLGTMCommitAuthors, selfLGTMCommitAuthorsAndCommitters:
+	default:
+		return fmt.Errorf("unsupported self lgtm policy:%s", c.SelfLGTMPolicy)
+	}
+
+	switch c.SelfApprovalPolicy {
+	case "", selfApprovalAllow, selfApprovalForbid:
+	case selfApprovalWithExtraLGTM:
+		if c.LgtmCountsRequired <= 1 {
+			return fmt.Errorf("self approval policy:%s needs lgtm_counts_required greater than 1", c.SelfApprovalPolicy)
+		}
+	default:
+		return fmt.Errorf("unsupported self approval policy:%s", c.SelfApprovalPolicy)
+	}
+
 	for _, v := range c.FreezeFile {
-		return v.validate()
+		if err := v.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := c.CITrigger.validate(); err != nil {
+		return err
+	}
+
+	if err := c.ReviewPeriod.validate(); err != nil {
+		return err
 	}
 
+	if err := c.LinkedIssues.validate(); err != nil {
+		return err
+	}
+
+	if err := c.PRPolicy.validate(); err != nil {
+		return err
+	}
+
+	if err := c.CommitRules.validate(); err != nil {
+		return err
+	}
+
+	if err := c.Size.validate(); err != nil {
+		return err
+	}
+
+	for i := range c.PathLabels {
+		if err := c.PathLabels[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.ProtectedPaths {
+		if err := c.ProtectedPaths[i].validate(); err != nil {
+			return err
+		}
+	}
+
+	if c.Size.ExtraLgtmForXL > 0 && c.LgtmCountsRequired <= 1 {
+		return fmt.Errorf("extra lgtm for XL needs lgtm_counts_required greater than 1")
+	}
+
+	t, err := newMessageTemplates(c.Language, c.Messages)
+	if err != nil {
+		return err
+	}
+	c.templates = t
+
 	return c.PluginForRepo.Validate()
 }
 
+// lgtmCountsRequiredFor returns the number of lgtm needed by the PR with these labels,
+// which is more if the PR is approved by its author or it is too large.
+func (c *botConfig) lgtmCountsRequiredFor(labels sets.String) uint {
+	n := c.LgtmCountsRequired
+
+	if c.SelfApprovalPolicy == selfApprovalWithExtraLGTM && labels.Has(selfApprovedLabel) {
+		n += c.SelfApprovalExtraLgtm
+	}
+
+	if c.Size.Enabled && labels.HasAny(sizeLabelPrefix+"XL", sizeLabelPrefix+"XXL") {
+		n += c.Size.ExtraLgtmForXL
+	}
+
+	return n
+}
+
+// message renders the message of id in the language of repo.
+func (c *botConfig) message(id string, data msgData) string {
+	t := c.templates
+	if t == nil {
+		// the built-in english message will be used if failed.
+		t, _ = newMessageTemplates(c.Language, c.Messages)
+	}
+
+	return t.render(id, data)
+}
+
 type freezeFile struct {
 	Owner  string `json:"owner" required:"true"`
 	Repo   string `json:"repo" required:"true"`
@@ -139,3 +343,397 @@ func (f freezeFile) validate() error {
 
 	return nil
 }
+
+type staleConfig struct {
+	// DaysUntilStale is the number of days of inactivity before a PR is marked as stale.
+	// The default value is 0 which means the stale PRs will not be handled.
+	DaysUntilStale uint `json:"days_until_stale,omitempty"`
+
+	// DaysUntilClose is the number of days of inactivity before a stale PR is closed.
+	// The default value is 0 which means the stale PRs will not be closed.
+	DaysUntilClose uint `json:"days_until_close,omitempty"`
+
+	// ExemptLabels specifies the labels which prevent a PR from being marked as stale.
+	ExemptLabels []string `json:"exempt_labels,omitempty"`
+}
+
+type ciTriggerConfig struct {
+	// Disabled is a switch used to stop triggering the CI when the source branch of PR is changed.
+	Disabled bool `json:"disabled,omitempty"`
+
+	// Comments specifies the comments posted to trigger the CI.
+	// The default value is /retest unless the endpoint is set.
+	Comments []string `json:"comments,omitempty"`
+
+	// Endpoint is the http endpoint of CI which will be called directly instead of commenting.
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// RequiredLabels specifies the labels which the PR must have one of at least
+	// before the CI is triggered. It is usually the labels set by the CI.
+	RequiredLabels []string `json:"required_labels,omitempty"`
+
+	// RequireOkToTest is a switch used to stop triggering the CI for the PRs of
+	// untrusted contributors until a collaborator comments /ok-to-test.
+	// The contributor who has the permission of repo or is in the OWNERS file is trusted.
+	RequireOkToTest bool `json:"require_ok_to_test,omitempty"`
+
+	// TrustPriorContributors specifies that the contributor who has merged PRs
+	// in the repo is trusted too. It works only when RequireOkToTest is true.
+	TrustPriorContributors bool `json:"trust_prior_contributors,omitempty"`
+}
+
+func (c *ciTriggerConfig) setDefault() {
+	if len(c.Comments) == 0 && c.Endpoint == "" {
+		c.Comments = []string{retestCommand}
+	}
+}
+
+func (c *ciTriggerConfig) validate() error {
+	if c.Endpoint == "" {
+		return nil
+	}
+
+	if _, err := url.ParseRequestURI(c.Endpoint); err != nil {
+		return fmt.Errorf("invalid endpoint of ci trigger: %s", err.Error())
+	}
+
+	return nil
+}
+
+type reviewPeriodConfig struct {
+	// MinOpenDuration is the minimum duration, such as 24h, that a PR must stay open
+	// before it is merged. It is disabled when empty.
+	MinOpenDuration string `json:"min_open_duration,omitempty"`
+
+	// Branches overrides MinOpenDuration for the PRs of the specified branches.
+	// The key is the name of branch and the value is the duration.
+	Branches map[string]string `json:"branches,omitempty"`
+
+	// SinceLastPush specifies that the duration is counted from the last push of PR
+	// if it is later than the creation of PR, so that the new changes are reviewed too.
+	SinceLastPush bool `json:"since_last_push,omitempty"`
+
+	minOpenDuration time.Duration
+	branches        map[string]time.Duration
+}
+
+func (c *reviewPeriodConfig) validate() error {
+	parse := func(s string) (time.Duration, error) {
+		if s == "" {
+			return 0, nil
+		}
+
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return 0, fmt.Errorf("invalid min open duration:%s, err:%s", s, err.Error())
+		}
+
+		if d < 0 {
+			return 0, fmt.Errorf("min open duration:%s can't be negative", s)
+		}
+
+		return d, nil
+	}
+
+	d, err := parse(c.MinOpenDuration)
+	if err != nil {
+		return err
+	}
+	c.minOpenDuration = d
+
+	c.branches = make(map[string]time.Duration, len(c.Branches))
+	for b, v := range c.Branches {
+		if c.branches[b], err = parse(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// durationFor returns the minimum open duration of the PRs of the branch.
+func (c *reviewPeriodConfig) durationFor(branch string) time.Duration {
+	if d, ok := c.branches[branch]; ok {
+		return d
+	}
+
+	return c.minOpenDuration
+}
+
+type prPolicyConfig struct {
+	// TitlePattern is the regular expression which the title of PR must match,
+	// such as the prefix of conventional commits. The PR without a matched title
+	// will be labeled with invalid-title.
+	TitlePattern string `json:"title_pattern,omitempty"`
+
+	// RequiredSections specifies the texts, such as the headings of the PR template,
+	// which the description of PR must contain.
+	RequiredSections []string `json:"required_sections,omitempty"`
+
+	// MinDescriptionLength is the minimum number of characters of the description of PR.
+	MinDescriptionLength uint `json:"min_description_length,omitempty"`
+
+	// RequireLinkedIssue specifies that the description of PR must refer to a gitee issue.
+	// The PR without it will be labeled with needs-issue.
+	RequireLinkedIssue bool `json:"require_linked_issue,omitempty"`
+
+	// RequireLinkedIssueForLabels is the same as RequireLinkedIssue, but only for
+	// the PRs which have one of these labels, such as kind/bug.
+	RequireLinkedIssueForLabels []string `json:"require_linked_issue_for_labels,omitempty"`
+
+	titlePattern *regexp.Regexp
+}
+
+func (c *prPolicyConfig) validate() error {
+	if c.TitlePattern == "" {
+		c.titlePattern = nil
+
+		return nil
+	}
+
+	v, err := regexp.Compile(c.TitlePattern)
+	if err != nil {
+		return fmt.Errorf("invalid title pattern:%s, err:%s", c.TitlePattern, err.Error())
+	}
+	c.titlePattern = v
+
+	return nil
+}
+
+func (c *prPolicyConfig) isTitleValid(title string) bool {
+	return c.titlePattern == nil || c.titlePattern.MatchString(title)
+}
+
+func (c *prPolicyConfig) requireLinkedIssue(labels sets.String) bool {
+	return c.RequireLinkedIssue || labels.HasAny(c.RequireLinkedIssueForLabels...)
+}
+
+type linkedIssuesConfig struct {
+	// CloseOnMerge is a switch used to comment on and close the issues after the PR is merged,
+	// which are referred to with the closing keywords, such as Fixes #I1ABCD and Closes org/repo#I1ABCD,
+	// in the description or commits of PR.
+	CloseOnMerge bool `json:"close_on_merge,omitempty"`
+
+	// State is the state which the issues are changed to. Valid options are
+	// open, progressing, closed and rejected. The default value is closed.
+	State string `json:"state,omitempty"`
+}
+
+func (c *linkedIssuesConfig) setDefault() {
+	if c.State == "" {
+		c.State = "closed"
+	}
+}
+
+func (c *linkedIssuesConfig) validate() error {
+	switch c.State {
+	case "open", "progressing", "closed", "rejected":
+		return nil
+	default:
+		return fmt.Errorf("unsupported state of linked issues:%s", c.State)
+	}
+}
+
+type commitRulesConfig struct {
+	// MaxCommits is the maximum number of commits of PR. 0 means no limit.
+	MaxCommits uint `json:"max_commits,omitempty"`
+
+	// ForbidFixup specifies that the PR can't have the commits created by
+	// git commit --fixup or --squash, whose titles start with fixup! or squash!.
+	ForbidFixup bool `json:"forbid_fixup,omitempty"`
+
+	// MessagePattern is the regular expression which the title of each commit must match.
+	MessagePattern string `json:"message_pattern,omitempty"`
+
+	// SquashInstead specifies that the PR violating the rules is merged with
+	// the squash method instead of being blocked.
+	SquashInstead bool `json:"squash_instead,omitempty"`
+
+	messagePattern *regexp.Regexp
+}
+
+func (c *commitRulesConfig) validate() error {
+	if c.MessagePattern == "" {
+		c.messagePattern = nil
+
+		return nil
+	}
+
+	v, err := regexp.Compile(c.MessagePattern)
+	if err != nil {
+		return fmt.Errorf("invalid commit message pattern:%s, err:%s", c.MessagePattern, err.Error())
+	}
+	c.messagePattern = v
+
+	return nil
+}
+
+func (c *commitRulesConfig) enabled() bool {
+	return c.MaxCommits > 0 || c.ForbidFixup || c.MessagePattern != ""
+}
+
+func (c *commitRulesConfig) isMessageValid(title string) bool {
+	return c.messagePattern == nil || c.messagePattern.MatchString(title)
+}
+
+type sizeConfig struct {
+	// Enabled is a switch used to label the PR with size/XS, size/S, size/M,
+	// size/L, size/XL or size/XXL when it is opened or its source branch is changed.
+	Enabled bool `json:"enabled,omitempty"`
+
+	// The thresholds are the minimum numbers of changed lines of each size.
+	// The default values are 10, 30, 100, 500 and 1000. The PR changing
+	// fewer lines than S is labeled with size/XS.
+	S   int `json:"s,omitempty"`
+	M   int `json:"m,omitempty"`
+	L   int `json:"l,omitempty"`
+	XL  int `json:"xl,omitempty"`
+	XXL int `json:"xxl,omitempty"`
+
+	// ExcludedPaths specifies the path globs of files which are not counted,
+	// such as the generated or vendored files.
+	ExcludedPaths []string `json:"excluded_paths,omitempty"`
+
+	// ExtraLgtmForXL specifies the number of lgtm needed in addition to LgtmCountsRequired
+	// for the PR labeled with size/XL or size/XXL.
+	ExtraLgtmForXL uint `json:"extra_lgtm_for_xl,omitempty"`
+
+	excludedPaths []pathGlob
+}
+
+func (c *sizeConfig) setDefault() {
+	if c.S == 0 {
+		c.S = 10
+	}
+
+	if c.M == 0 {
+		c.M = 30
+	}
+
+	if c.L == 0 {
+		c.L = 100
+	}
+
+	if c.XL == 0 {
+		c.XL = 500
+	}
+
+	if c.XXL == 0 {
+		c.XXL = 1000
+	}
+}
+
+func (c *sizeConfig) validate() error {
+	if !(0 < c.S && c.S < c.M && c.M < c.L && c.L < c.XL && c.XL < c.XXL) {
+		return fmt.Errorf("the thresholds of size must be positive and increasing")
+	}
+
+	v, err := newPathGlobs(c.ExcludedPaths)
+	if err != nil {
+		return err
+	}
+	c.excludedPaths = v
+
+	return nil
+}
+
+// label returns the size label of PR changing n lines.
+func (c *sizeConfig) label(n int) string {
+	s := "XS"
+
+	switch {
+	case n >= c.XXL:
+		s = "XXL"
+	case n >= c.XL:
+		s = "XL"
+	case n >= c.L:
+		s = "L"
+	case n >= c.M:
+		s = "M"
+	case n >= c.S:
+		s = "S"
+	}
+
+	return sizeLabelPrefix + s
+}
+
+type pathLabel struct {
+	// Paths specifies the path globs of files, such as docs/** and *.spec.
+	Paths []string `json:"paths" required:"true"`
+
+	// Label is added to the PR which changes any file matching the Paths.
+	Label string `json:"label" required:"true"`
+
+	paths []pathGlob
+}
+
+func (p *pathLabel) validate() error {
+	if p.Label == "" {
+		return fmt.Errorf("missing label of path labels")
+	}
+
+	if len(p.Paths) == 0 {
+		return fmt.Errorf("missing paths of label:%s", p.Label)
+	}
+
+	v, err := newPathGlobs(p.Paths)
+	if err != nil {
+		return err
+	}
+	p.paths = v
+
+	return nil
+}
+
+type protectedPath struct {
+	// Paths specifies the path globs of the protected files, such as OWNERS and *.spec.
+	Paths []string `json:"paths" required:"true"`
+
+	// Approvers specifies the users one of whom must comment /approve
+	// after the last push of PR which changes the protected files.
+	// The /approve must be accepted by the bot, so the approver needs the permission of it.
+	Approvers []string `json:"approvers" required:"true"`
+
+	paths     []pathGlob
+	approvers sets.String
+}
+
+func (p *protectedPath) validate() error {
+	if len(p.Paths) == 0 {
+		return fmt.Errorf("missing paths of protected paths")
+	}
+
+	if len(p.Approvers) == 0 {
+		return fmt.Errorf("missing approvers of protected paths:%v", p.Paths)
+	}
+
+	v, err := newPathGlobs(p.Paths)
+	if err != nil {
+		return err
+	}
+	p.paths = v
+
+	p.approvers = sets.NewString()
+	for _, a := range p.Approvers {
+		p.approvers.Insert(strings.ToLower(a))
+	}
+
+	return nil
+}
+
+type nativeReviewConfig struct {
+	// ReviewAsLgtm is a switch used to treat the review pass of PR on gitee as the lgtm
+	// of the reviewer, if the reviewer has the permission to add lgtm. The passes made
+	// before the last push are ignored until they are made again.
+	ReviewAsLgtm bool `json:"review_as_lgtm,omitempty"`
+
+	// RequireTestPass specifies that the PR can be merged only when it has been
+	// passed by the number of testers required on gitee, at least one.
+	RequireTestPass bool `json:"require_test_pass,omitempty"`
+}
+
+// enabled reports whether the review and test on gitee are used. If so, the numbers of
+// reviewers and testers required by gitee are kept and checked before merging.
+func (c *nativeReviewConfig) enabled() bool {
+	return c.ReviewAsLgtm || c.RequireTestPass
+}
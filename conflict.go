@@ -0,0 +1,180 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	libconfig "github.com/opensourceways/community-robot-lib/config"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	needsRebaseLabel = "needs-rebase"
+
+	// conflictCheckDelay is the time to wait before re-checking the conflicts after
+	// the target branch is pushed, because gitee computes the mergeable asynchronously.
+	conflictCheckDelay = time.Minute
+)
+
+func (bot *robot) handlePRConflict(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	if !cfg.TrackConflicts || giteeclient.GetPullRequestAction(e) == giteeclient.PRActionClosed {
+		return nil
+	}
+
+	v := e.GetPullRequest()
+	if v == nil || v.State != "open" {
+		return nil
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+
+	switch giteeclient.GetPullRequestAction(e) {
+	case giteeclient.PRActionOpened, giteeclient.PRActionChangedSourceBranch:
+		// the mergeable in the event is not recomputed yet, the same as the push to the target branch.
+		time.AfterFunc(conflictCheckDelay, func() {
+			c := bot.latestConfigFor(pr.Org, pr.Repo, cfg)
+			if err := bot.recheckConflict(pr.Org, pr.Repo, pr.Number, c, log); err != nil {
+				log.WithError(err).Errorf("re-check the conflict of pr: %s/%s/%d", pr.Org, pr.Repo, pr.Number)
+			}
+		})
+
+		return nil
+	}
+
+	return bot.syncConflictLabel(pr.Org, pr.Repo, pr.Number, v.GetMergeable(), pr.Labels, pr.Author, cfg, log)
+}
+
+// recheckConflict syncs the conflict label of PR with its latest mergeable.
+func (bot *robot) recheckConflict(org, repo string, number int32, cfg *botConfig, log *logrus.Entry) error {
+	if !cfg.TrackConflicts {
+		return nil
+	}
+
+	pr, err := bot.cli.GetGiteePullRequest(org, repo, number)
+	if err != nil || pr.State != "open" {
+		return err
+	}
+
+	return bot.syncConflictLabel(org, repo, number, pr.Mergeable, getPRLabels(&pr), getPRAuthor(&pr), cfg, log)
+}
+
+// handlePushEvent re-checks the conflicts of the open PRs of the branch which is pushed.
+// The check is delayed until gitee recomputes the mergeable of the PRs, and the ones
+// which are still not recomputed then are left to the reconciler.
+func (bot *robot) handlePushEvent(e *sdk.PushEvent, pc libconfig.PluginConfig, log *logrus.Entry) error {
+	bot.setConfig(pc)
+
+	key := genPushEventKey(e)
+	if bot.isDuplicateEvent(key, log) {
+		return nil
+	}
+
+	err := bot.processPushEvent(e, pc, log)
+	bot.finishEvent(key, err, log)
+
+	return err
+}
+
+func (bot *robot) processPushEvent(e *sdk.PushEvent, pc libconfig.PluginConfig, log *logrus.Entry) error {
+	if e.Repository == nil || e.Ref == nil {
+		return nil
+	}
+
+	org, repo := e.Repository.Namespace, e.Repository.Path
+
+	cfg, err := bot.getConfig(pc, org, repo)
+	if err != nil {
+		return err
+	}
+
+	if !cfg.TrackConflicts {
+		return nil
+	}
+
+	branch := strings.TrimPrefix(*e.Ref, "refs/heads/")
+
+	time.AfterFunc(conflictCheckDelay, func() {
+		if err := bot.syncConflictLabels(org, repo, branch, bot.latestConfigFor(org, repo, cfg), log); err != nil {
+			log.WithError(err).Errorf("re-check the conflicts of prs of branch: %s/%s/%s", org, repo, branch)
+		}
+	})
+
+	return nil
+}
+
+// syncConflictLabels syncs the conflict labels of the open PRs of the branch.
+func (bot *robot) syncConflictLabels(org, repo, branch string, cfg *botConfig, log *logrus.Entry) error {
+	if !cfg.TrackConflicts {
+		return nil
+	}
+
+	prs, err := bot.cli.GetPullRequests(org, repo, giteeclient.ListPullRequestOpt{State: "open", Base: branch})
+	if err != nil {
+		return err
+	}
+
+	merr := utils.NewMultiErrors()
+
+	for i := range prs {
+		pr := &prs[i]
+
+		err := bot.syncConflictLabel(org, repo, pr.Number, pr.Mergeable, getPRLabels(pr), getPRAuthor(pr), cfg, log)
+		if err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+// syncConflictLabel adds the needs-rebase label and notifies the author when the PR
+// becomes unmergeable, and removes the label once it is mergeable again.
+func (bot *robot) syncConflictLabel(
+	org, repo string, number int32, mergeable bool,
+	labels sets.String, author string, cfg *botConfig, log *logrus.Entry,
+) error {
+	if mergeable {
+		if !labels.Has(needsRebaseLabel) {
+			return nil
+		}
+
+		return bot.cli.RemovePRLabel(org, repo, number, needsRebaseLabel)
+	}
+
+	if labels.Has(needsRebaseLabel) {
+		return nil
+	}
+
+	if err := bot.createLabelIfNeed(org, repo, needsRebaseLabel); err != nil {
+		log.WithError(err).Errorf("create repo label: %s", needsRebaseLabel)
+	}
+
+	if err := bot.cli.AddPRLabel(org, repo, number, needsRebaseLabel); err != nil {
+		return err
+	}
+
+	return bot.cli.CreatePRComment(org, repo, number, cfg.message(
+		commentNeedsRebase, msgData{"Author": author, "Label": needsRebaseLabel},
+	))
+}
+
+func getPRLabels(pr *sdk.PullRequest) sets.String {
+	labels := sets.NewString()
+	for _, item := range pr.Labels {
+		labels.Insert(item.Name)
+	}
+
+	return labels
+}
+
+func getPRAuthor(pr *sdk.PullRequest) string {
+	if pr.User == nil {
+		return ""
+	}
+
+	return pr.User.Login
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestSyncConflictLabels(t *testing.T) {
+	pr := func(mergeable bool, labels ...string) sdk.PullRequest {
+		v := sdk.PullRequest{Number: 1, State: "open", Mergeable: mergeable, User: &sdk.UserBasic{Login: "author"}}
+		for _, l := range labels {
+			v.Labels = append(v.Labels, sdk.Label{Name: l})
+		}
+
+		return v
+	}
+
+	cases := []struct {
+		name     string
+		pr       sdk.PullRequest
+		disabled bool
+		added    []string
+		removed  []string
+		notified bool
+	}{
+		{name: "conflicts", pr: pr(false), added: []string{needsRebaseLabel}, notified: true},
+		{name: "still conflicts", pr: pr(false, needsRebaseLabel)},
+		{name: "resolved", pr: pr(true, needsRebaseLabel), removed: []string{needsRebaseLabel}},
+		{name: "mergeable", pr: pr(true)},
+		{name: "disabled", pr: pr(false), disabled: true},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{TrackConflicts: !tc.disabled}
+		cfg.setDefault()
+
+		cli := newFakeClient()
+		cli.prs = []sdk.PullRequest{tc.pr}
+		cli.pr = tc.pr
+
+		bot := newTestRobot(cli)
+
+		check := func(method string, err error) {
+			if err != nil {
+				t.Fatalf("%s: %s: %v", tc.name, method, err)
+			}
+
+			if strings.Join(cli.added, ",") != strings.Join(tc.added, ",") ||
+				strings.Join(cli.removed, ",") != strings.Join(tc.removed, ",") {
+				t.Errorf(
+					"%s: %s: added = %v, removed = %v, want %v, %v",
+					tc.name, method, cli.added, cli.removed, tc.added, tc.removed,
+				)
+			}
+
+			if notified := len(cli.created) == 1 && strings.Contains(cli.created[0], "@author"); notified != tc.notified {
+				t.Errorf("%s: %s: comments = %q", tc.name, method, cli.created)
+			}
+
+			cli.added, cli.removed, cli.created = nil, nil, nil
+		}
+
+		check("syncConflictLabels", bot.syncConflictLabels("org", "repo", "master", cfg, newTestLog()))
+		check("recheckConflict", bot.recheckConflict("org", "repo", 1, cfg, newTestLog()))
+	}
+}
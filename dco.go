@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	dcoPassedLabel = "dco-passed"
+	dcoFailedLabel = "dco-failed"
+)
+
+var regSignedOffBy = regexp.MustCompile(`(?mi)^signed-off-by:\s*(.+)$`)
+
+// handleDCO checks whether every commit of PR is signed off by its author
+// when the PR is opened or its source branch is changed.
+func (bot *robot) handleDCO(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	if action := giteeclient.GetPullRequestAction(e); action != giteeclient.PRActionOpened &&
+		action != giteeclient.PRActionChangedSourceBranch {
+		return nil
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+
+	unsigned, err := bot.syncDCOLabel(pr, cfg, log)
+	if err != nil || len(unsigned) == 0 {
+		return err
+	}
+
+	var items []string
+	for i := range unsigned {
+		c := &unsigned[i]
+		title := strings.SplitN(strings.TrimSpace(c.Commit.Message), "\n", 2)[0]
+		items = append(items, fmt.Sprintf("- `%s` %s", shortSHA(c.Sha), title))
+	}
+
+	return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, cfg.message(
+		commentDCOFailed, msgData{"Author": pr.Author, "Commits": strings.Join(items, "\n")},
+	))
+}
+
+// syncDCOLabel labels the PR with dco-passed or dco-failed according to its commits,
+// and returns the commits which are not signed off. It is also used by the reconciler
+// and /check-pr to recheck the PRs whose events were missed.
+func (bot *robot) syncDCOLabel(
+	pr giteeclient.PRInfo, cfg *botConfig, log *logrus.Entry,
+) ([]sdk.PullRequestCommits, error) {
+	if !cfg.CheckDCO {
+		return nil, nil
+	}
+
+	commits, err := bot.cli.GetPRCommits(pr.Org, pr.Repo, pr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	unsigned := getUnsignedCommits(commits)
+
+	add, remove := dcoPassedLabel, dcoFailedLabel
+	if len(unsigned) > 0 {
+		add, remove = remove, add
+	}
+
+	if pr.Labels.Has(remove) {
+		if err := bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, remove); err != nil {
+			return unsigned, err
+		}
+	}
+
+	if !pr.Labels.Has(add) {
+		if err := bot.createLabelIfNeed(pr.Org, pr.Repo, add); err != nil {
+			log.WithError(err).Errorf("create repo label: %s", add)
+		}
+
+		if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, add); err != nil {
+			return unsigned, err
+		}
+	}
+
+	return unsigned, nil
+}
+
+// checkDCO checks the commits rather than the dco-passed label,
+// because the label may be stale or added by anyone who can edit labels.
+func (m *mergeHelper) checkDCO(log *logrus.Entry) mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkDCO, nil)}
+
+	if !m.cfg.CheckDCO {
+		c.Passed = true
+
+		return c
+	}
+
+	commits, err := m.cli.GetPRCommits(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		log.WithError(err).Error("get the commits of pr")
+		c.Detail = m.cfg.message(msgCommitsUnknown, nil)
+
+		return c
+	}
+
+	unsigned := getUnsignedCommits(commits)
+	if c.Passed = len(unsigned) == 0; !c.Passed {
+		var v []string
+		for i := range unsigned {
+			v = append(v, fmt.Sprintf("`%s`", shortSHA(unsigned[i].Sha)))
+		}
+
+		c.Detail = m.cfg.message(msgUnsignedCommits, msgData{"Commits": strings.Join(v, ", ")})
+	}
+
+	return c
+}
+
+// getUnsignedCommits returns the commits which are not signed off by their authors.
+func getUnsignedCommits(commits []sdk.PullRequestCommits) []sdk.PullRequestCommits {
+	var r []sdk.PullRequestCommits
+
+	for i := range commits {
+		c := &commits[i]
+		if c.Commit == nil {
+			continue
+		}
+
+		author := commitIdentity{}
+		if c.Commit.Author != nil {
+			author.name = c.Commit.Author.Name
+			author.email = c.Commit.Author.Email
+		}
+
+		if !isSignedOffBy(c.Commit.Message, author) {
+			r = append(r, *c)
+		}
+	}
+
+	return r
+}
+
+// isSignedOffBy reports whether the message has a Signed-off-by trailer of the author.
+func isSignedOffBy(message string, author commitIdentity) bool {
+	if author.email == "" {
+		return false
+	}
+
+	for _, m := range regSignedOffBy.FindAllStringSubmatch(message, -1) {
+		if v := parseIdentity(m[1]); strings.EqualFold(v.email, author.email) {
+			return true
+		}
+	}
+
+	return false
+}
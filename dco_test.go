@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestIsSignedOffBy(t *testing.T) {
+	author := commitIdentity{name: "John", email: "john@corp.com"}
+
+	cases := []struct {
+		name    string
+		message string
+		author  commitIdentity
+		want    bool
+	}{
+		{name: "signed", message: "fix: x\n\nSigned-off-by: John <john@corp.com>", author: author, want: true},
+		{name: "case insensitive", message: "fix: x\n\nsigned-off-by: J <JOHN@corp.com>", author: author, want: true},
+		{name: "other email", message: "fix: x\n\nSigned-off-by: John <john@mail.com>", author: author},
+		{name: "not trailer", message: "fix: x Signed-off-by: John <john@corp.com>", author: author},
+		{name: "no email", message: "fix: x\n\nSigned-off-by: John", author: author},
+		{name: "no author email", message: "fix: x\n\nSigned-off-by: John <john@corp.com>"},
+		{
+			name:    "one of many",
+			message: "fix: x\n\nSigned-off-by: Jane <jane@corp.com>\nSigned-off-by: John <john@corp.com>",
+			author:  author,
+			want:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		if v := isSignedOffBy(tc.message, tc.author); v != tc.want {
+			t.Errorf("%s: isSignedOffBy = %v, want %v", tc.name, v, tc.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// eventDeduplicator remembers the events which have been handled, so that
+// the redeliveries of webhook will not be handled again.
+// It keeps at most 'limit' keys in memory and the oldest one will be evicted first.
+// The keys will also be written to a file if the path of store is set,
+// which makes the deduplication survive restarts.
+type eventDeduplicator struct {
+	lock  sync.Mutex
+	limit int
+	keys  []string
+	seen  sets.String
+	store *eventFileStore
+
+	// inflight holds the keys of events being handled. The channel is closed
+	// when the event is done, which wakes up the redeliveries waiting for it.
+	inflight map[string]chan struct{}
+}
+
+func newEventDeduplicator(limit int, storePath string) (*eventDeduplicator, error) {
+	d := &eventDeduplicator{
+		limit:    limit,
+		seen:     sets.NewString(),
+		inflight: map[string]chan struct{}{},
+	}
+
+	if storePath == "" {
+		return d, nil
+	}
+
+	s, keys, err := newEventFileStore(storePath)
+	if err != nil {
+		return nil, err
+	}
+	d.store = s
+
+	for _, k := range keys {
+		d.record(k)
+	}
+
+	return d, nil
+}
+
+// isDuplicate reports whether the event identified by key has been handled.
+// If the event is being handled, it waits until that is done, so that the
+// redelivery is handled again only if the first attempt failed.
+// done must be called with the key if it is not a duplicate.
+func (d *eventDeduplicator) isDuplicate(key string) bool {
+	if d == nil || key == "" {
+		return false
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	for {
+		if d.seen.Has(key) {
+			return true
+		}
+
+		ch, ok := d.inflight[key]
+		if !ok {
+			break
+		}
+
+		d.lock.Unlock()
+		<-ch
+		d.lock.Lock()
+	}
+
+	d.inflight[key] = make(chan struct{})
+
+	return false
+}
+
+// done remembers and persists the key if the event is handled successfully.
+// Only the handled keys are persisted, and a failed one is just released,
+// so that the redelivery of it can be handled again.
+func (d *eventDeduplicator) done(key string, handled bool) error {
+	if d == nil || key == "" {
+		return nil
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if ch, ok := d.inflight[key]; ok {
+		delete(d.inflight, key)
+		close(ch)
+	}
+
+	if !handled || d.seen.Has(key) {
+		return nil
+	}
+
+	d.record(key)
+
+	if d.store == nil {
+		return nil
+	}
+
+	if d.store.lines >= 2*d.limit {
+		return d.store.compact(d.keys)
+	}
+
+	return d.store.append(key)
+}
+
+func (d *eventDeduplicator) record(key string) {
+	if d.seen.Has(key) {
+		return
+	}
+
+	d.seen.Insert(key)
+	d.keys = append(d.keys, key)
+
+	if n := len(d.keys) - d.limit; n > 0 {
+		d.seen.Delete(d.keys[:n]...)
+		d.keys = append([]string{}, d.keys[n:]...)
+	}
+}
+
+func (d *eventDeduplicator) stop() {
+	if d == nil || d.store == nil {
+		return
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	d.store.close()
+}
+
+// eventFileStore saves the keys of handled events line by line.
+type eventFileStore struct {
+	path  string
+	file  *os.File
+	lines int
+}
+
+func newEventFileStore(path string) (*eventFileStore, []string, error) {
+	keys, err := readEventKeys(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &eventFileStore{path: path, file: f, lines: len(keys)}, keys, nil
+}
+
+func readEventKeys(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var keys []string
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if k := strings.TrimSpace(s.Text()); k != "" {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys, s.Err()
+}
+
+func (s *eventFileStore) append(key string) error {
+	if _, err := s.file.WriteString(key + "\n"); err != nil {
+		return err
+	}
+
+	s.lines++
+
+	return nil
+}
+
+// compact rewrites the file with the keys which are still remembered.
+// The file being used is kept if it fails.
+func (s *eventFileStore) compact(keys []string) error {
+	tmp := s.path + ".tmp"
+
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+
+	content := strings.Join(keys, "\n") + "\n"
+	if _, err = f.WriteString(content); err == nil {
+		// the opened file refers to the new content after the rename.
+		err = os.Rename(tmp, s.path)
+	}
+
+	if err != nil {
+		f.Close()
+		os.Remove(tmp)
+
+		return err
+	}
+
+	s.file.Close()
+	s.file = f
+	s.lines = len(keys)
+
+	return nil
+}
+
+func (s *eventFileStore) close() {
+	s.file.Close()
+}
+
+// genPREventKey builds the key from the fields which are the same among
+// the redeliveries of an event, because the payload includes the timestamp of delivery.
+func genPREventKey(e *sdk.PullRequestEvent) string {
+	hook := e.GetPullRequest()
+	if hook == nil {
+		return ""
+	}
+
+	// the type of time in hook may vary, so it is marshaled as it is.
+	updatedAt, err := json.Marshal(hook.UpdatedAt)
+	if err != nil {
+		return ""
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+
+	return fmt.Sprintf(
+		"pr/%s/%s/%d/%s",
+		pr.Org, pr.Repo, pr.Number,
+		hashKey(
+			giteeclient.GetPullRequestAction(e), hook.State, pr.HeadSHA,
+			string(updatedAt), strings.Join(pr.Labels.List(), ","),
+		),
+	)
+}
+
+func genPushEventKey(e *sdk.PushEvent) string {
+	if e.Repository == nil || e.Ref == nil || e.After == nil {
+		return ""
+	}
+
+	return fmt.Sprintf(
+		"push/%s/%s/%s/%s",
+		e.Repository.Namespace, e.Repository.Path, *e.Ref, *e.After,
+	)
+}
+
+// genNoteEventKey uses the id of note as the key of creating comment event.
+// The other note events are not deduplicated, because they are not handled.
+func genNoteEventKey(e *sdk.NoteEvent) string {
+	ne := giteeclient.NewPRNoteEvent(e)
+	if !ne.IsCreatingCommentEvent() || e.Comment == nil {
+		return ""
+	}
+
+	org, repo := giteeclient.GetOwnerAndRepoByNoteEvent(e)
+
+	return fmt.Sprintf("note/%s/%s/%d", org, repo, e.Comment.Id)
+}
+
+func hashKey(fields ...string) string {
+	v := sha256.Sum256([]byte(strings.Join(fields, "\n")))
+
+	return hex.EncodeToString(v[:])
+}
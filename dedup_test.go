@@ -0,0 +1,145 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEventDeduplicator(t *testing.T) {
+	d, err := newEventDeduplicator(2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		key       string
+		handled   bool
+		duplicate bool
+	}{
+		{key: "a", handled: true},
+		{key: "a", duplicate: true},
+		{key: "b", handled: false},
+		// the failed event can be handled again.
+		{key: "b", handled: true},
+		{key: "c", handled: true},
+		// the oldest key is evicted.
+		{key: "a", handled: true},
+		{key: "", handled: true},
+		{key: "", handled: true},
+	}
+
+	for i, s := range steps {
+		if v := d.isDuplicate(s.key); v != s.duplicate {
+			t.Fatalf("step %d: isDuplicate(%q) = %v, want %v", i, s.key, v, s.duplicate)
+		}
+
+		if s.duplicate {
+			continue
+		}
+
+		if err := d.done(s.key, s.handled); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+}
+
+func TestEventDeduplicatorInflight(t *testing.T) {
+	cases := []struct {
+		name    string
+		handled bool
+		want    bool
+	}{
+		{name: "first attempt failed", handled: false, want: false},
+		{name: "first attempt handled", handled: true, want: true},
+	}
+
+	for _, tc := range cases {
+		d, err := newEventDeduplicator(2, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if d.isDuplicate("a") {
+			t.Fatalf("%s: the first delivery is a duplicate", tc.name)
+		}
+
+		// the redelivery arrives while the first attempt is being handled.
+		r := make(chan bool)
+		go func() {
+			r <- d.isDuplicate("a")
+		}()
+
+		select {
+		case <-r:
+			t.Fatalf("%s: the redelivery does not wait for the first attempt", tc.name)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		if err := d.done("a", tc.handled); err != nil {
+			t.Fatal(err)
+		}
+
+		if v := <-r; v != tc.want {
+			t.Errorf("%s: isDuplicate = %t, want %t", tc.name, v, tc.want)
+		}
+	}
+}
+
+func TestEventDeduplicatorStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events")
+
+	d, err := newEventDeduplicator(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, k := range []string{"a", "b", "c", "d", "e"} {
+		d.isDuplicate(k)
+		if err := d.done(k, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	d.isDuplicate("f")
+	if err := d.done("f", false); err != nil {
+		t.Fatal(err)
+	}
+
+	// "g" is being handled when the store is compacted.
+	d.isDuplicate("g")
+	d.isDuplicate("h")
+	if err := d.done("h", true); err != nil {
+		t.Fatal(err)
+	}
+
+	d.stop()
+
+	keys, err := readEventKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the evicted keys are compacted, "f" is not persisted because it failed
+	// and "g" is not persisted because it is not done.
+	want := []string{"d", "e", "h"}
+	if len(keys) != len(want) {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("keys = %v, want %v", keys, want)
+		}
+	}
+
+	d, err = newEventDeduplicator(2, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.stop()
+
+	if !d.isDuplicate("h") || d.isDuplicate("f") || d.isDuplicate("g") {
+		t.Fatal("the keys are not restored from the store")
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// fakeClient is an in-memory iClient of one repo which records the changes made by the robot.
+type fakeClient struct {
+	// permissions is the permission of users on the repo, the others have "read".
+	permissions map[string]string
+
+	pr       sdk.PullRequest
+	prs      []sdk.PullRequest
+	commits  []sdk.PullRequestCommits
+	files    []sdk.PullRequestFiles
+	comments []sdk.PullRequestComments
+	labels   sets.String
+
+	// the records of changes.
+	added    []string
+	removed  []string
+	created  []string
+	updated  map[int32]string
+	merged   bool
+	closed   bool
+	prParams []sdk.PullRequestUpdateParam
+
+	// calls counts the calls of each method.
+	calls map[string]int
+}
+
+func newFakeClient() *fakeClient {
+	return &fakeClient{
+		permissions: map[string]string{},
+		labels:      sets.NewString(),
+		updated:     map[int32]string{},
+		calls:       map[string]int{},
+	}
+}
+
+func (c *fakeClient) AddPRLabel(org, repo string, number int32, label string) error {
+	c.calls["AddPRLabel"]++
+	c.added = append(c.added, label)
+	c.labels.Insert(label)
+
+	return nil
+}
+
+func (c *fakeClient) RemovePRLabel(org, repo string, number int32, label string) error {
+	c.calls["RemovePRLabel"]++
+	c.removed = append(c.removed, label)
+	c.labels.Delete(label)
+
+	return nil
+}
+
+func (c *fakeClient) RemovePRLabels(org, repo string, number int32, labels []string) error {
+	c.calls["RemovePRLabels"]++
+	c.removed = append(c.removed, labels...)
+	c.labels.Delete(labels...)
+
+	return nil
+}
+
+func (c *fakeClient) CreatePRComment(org, repo string, number int32, comment string) error {
+	c.calls["CreatePRComment"]++
+	c.created = append(c.created, comment)
+
+	return nil
+}
+
+func (c *fakeClient) GetUserPermissionsOfRepo(org, repo, login string) (sdk.ProjectMemberPermission, error) {
+	c.calls["GetUserPermissionsOfRepo"]++
+
+	if p, ok := c.permissions[login]; ok {
+		return sdk.ProjectMemberPermission{Permission: p}, nil
+	}
+
+	return sdk.ProjectMemberPermission{Permission: "read"}, nil
+}
+
+func (c *fakeClient) GetPathContent(org, repo, path, ref string) (sdk.Content, error) {
+	c.calls["GetPathContent"]++
+
+	return sdk.Content{}, fmt.Errorf("%s is not found", path)
+}
+
+func (c *fakeClient) CreateRepoLabel(org, repo, label, color string) error {
+	c.calls["CreateRepoLabel"]++
+
+	return nil
+}
+
+func (c *fakeClient) GetRepoLabels(owner, repo string) ([]sdk.Label, error) {
+	c.calls["GetRepoLabels"]++
+
+	var r []sdk.Label
+	for _, l := range c.labels.List() {
+		r = append(r, sdk.Label{Name: l})
+	}
+
+	return r, nil
+}
+
+func (c *fakeClient) MergePR(owner, repo string, number int32, opt sdk.PullRequestMergePutParam) error {
+	c.calls["MergePR"]++
+	c.merged = true
+
+	return nil
+}
+
+func (c *fakeClient) UpdatePullRequest(
+	org, repo string, number int32, param sdk.PullRequestUpdateParam,
+) (sdk.PullRequest, error) {
+	c.calls["UpdatePullRequest"]++
+	c.prParams = append(c.prParams, param)
+
+	return c.pr, nil
+}
+
+func (c *fakeClient) GetPullRequests(
+	org, repo string, opts giteeclient.ListPullRequestOpt,
+) ([]sdk.PullRequest, error) {
+	c.calls["GetPullRequests"]++
+
+	var r []sdk.PullRequest
+	for _, pr := range c.prs {
+		if opts.State == "" || opts.State == "all" || pr.State == opts.State {
+			r = append(r, pr)
+		}
+	}
+
+	return r, nil
+}
+
+func (c *fakeClient) GetRepos(org string) ([]sdk.Project, error) {
+	c.calls["GetRepos"]++
+
+	return nil, nil
+}
+
+func (c *fakeClient) ClosePR(org, repo string, number int32) error {
+	c.calls["ClosePR"]++
+	c.closed = true
+
+	return nil
+}
+
+func (c *fakeClient) GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error) {
+	c.calls["GetGiteePullRequest"]++
+
+	return c.pr, nil
+}
+
+func (c *fakeClient) ListPRComments(org, repo string, number int32) ([]sdk.PullRequestComments, error) {
+	c.calls["ListPRComments"]++
+
+	return c.comments, nil
+}
+
+func (c *fakeClient) UpdatePRComment(org, repo string, commentID int32, comment string) error {
+	c.calls["UpdatePRComment"]++
+	c.updated[commentID] = comment
+
+	return nil
+}
+
+func (c *fakeClient) GetBot() (sdk.User, error) {
+	c.calls["GetBot"]++
+
+	return sdk.User{Login: "robot"}, nil
+}
+
+func (c *fakeClient) GetPRCommits(org, repo string, number int32) ([]sdk.PullRequestCommits, error) {
+	c.calls["GetPRCommits"]++
+
+	return c.commits, nil
+}
+
+func (c *fakeClient) GetIssue(org, repo, number string) (sdk.Issue, error) {
+	c.calls["GetIssue"]++
+
+	return sdk.Issue{Number: number, State: "open"}, nil
+}
+
+func (c *fakeClient) CreateIssueComment(org, repo string, number string, comment string) error {
+	c.calls["CreateIssueComment"]++
+
+	return nil
+}
+
+func (c *fakeClient) UpdateIssue(owner, number string, param sdk.IssueUpdateParam) (sdk.Issue, error) {
+	c.calls["UpdateIssue"]++
+
+	return sdk.Issue{Number: number, State: param.State}, nil
+}
+
+func (c *fakeClient) GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error) {
+	c.calls["GetPullRequestChanges"]++
+
+	return c.files, nil
+}
+
+func (c *fakeClient) CreatePullRequest(
+	org, repo, title, body, head, base string, canModify bool,
+) (sdk.PullRequest, error) {
+	c.calls["CreatePullRequest"]++
+
+	return sdk.PullRequest{Title: title, Body: body}, nil
+}
+
+func newTestRobot(cli *fakeClient) *robot {
+	return newRobot(cli, nil, nil, nil)
+}
+
+func newTestLog() *logrus.Entry {
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// newTestNoteEvent returns the event of a comment on the open PR 1 of org/repo whose author is "author".
+func newTestNoteEvent(commenter, comment string, labels ...string) *sdk.NoteEvent {
+	var l []sdk.LabelHook
+	for _, v := range labels {
+		l = append(l, sdk.LabelHook{Name: v})
+	}
+
+	return &sdk.NoteEvent{
+		Comment:    &sdk.NoteHook{Body: comment, User: &sdk.UserHook{Login: commenter}},
+		Repository: &sdk.ProjectHook{Namespace: "org", Path: "repo"},
+		PullRequest: &sdk.PullRequestHook{
+			Number: 1,
+			State:  "open",
+			Labels: l,
+			User:   &sdk.UserHook{Login: "author"},
+			Head:   &sdk.BranchHook{Ref: "feature", Sha: "head"},
+			Base:   &sdk.BranchHook{Ref: "master", Sha: "base"},
+		},
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"strings"
+)
+
+// helpCommand is a row of the command table in the reply of /help.
+type helpCommand struct {
+	Name       string
+	Permission string
+}
+
+var permissionMessages = map[commandPermission]string{
+	permissionAnyone:               msgPermissionAnyone,
+	permissionAuthorOrCollaborator: msgPermissionAuthorOrCollaborator,
+	permissionCollaborator:         msgPermissionCollaborator,
+}
+
+// replyHelp replies with the commands enabled for the repo and the conditions of merge.
+func (bot *robot) replyHelp(c *commandContext) error {
+	cfg := c.cfg
+
+	var cmds []helpCommand
+
+	add := func(name, usage string, p commandPermission) {
+		if usage != "" {
+			name += " " + usage
+		}
+
+		cmds = append(cmds, helpCommand{Name: name, Permission: cfg.message(permissionMessages[p], nil)})
+	}
+
+	for i := range bot.commands.specs {
+		s := &bot.commands.specs[i]
+		if !s.isEnabled(bot, cfg) {
+			continue
+		}
+
+		name := "/" + s.name
+
+		// the command is listed twice if the arguments need another permission.
+		if s.argsPermission != 0 && s.argsPermission != s.permission {
+			if s.minArgs == 0 {
+				add(name, "", s.permission)
+			}
+
+			add(name, s.usage, s.argsPermission)
+
+			continue
+		}
+
+		add(name, s.usage, s.permission)
+	}
+
+	pr := c.event.GetPRInfo()
+
+	return bot.cli.CreatePRComment(pr.Org, pr.Repo, pr.Number, cfg.message(commentHelp, msgData{
+		"Commands":    cmds,
+		"Conditions":  mergeConditions(cfg, pr.BaseRef),
+		"MergeMethod": string(cfg.MergeMethod),
+	}))
+}
+
+// mergeConditions describes the conditions checked by canMerge for the PR of branch.
+func mergeConditions(cfg *botConfig, branch string) []string {
+	r := []string{
+		cfg.message(msgHelpLGTM, msgData{"Count": cfg.LgtmCountsRequired}),
+		cfg.message(msgHelpRequiredLabels, msgData{
+			"Labels": strings.Join(append([]string{approvedLabel}, cfg.LabelsForMerge...), ", "),
+		}),
+	}
+
+	add := func(id string, data msgData) {
+		r = append(r, cfg.message(id, data))
+	}
+
+	if len(cfg.MissingLabelsForMerge) > 0 {
+		add(msgHelpForbiddenLabels, msgData{"Labels": strings.Join(cfg.MissingLabelsForMerge, ", ")})
+	}
+
+	switch cfg.SelfApprovalPolicy {
+	case selfApprovalForbid:
+		add(msgHelpSelfApprovalForbidden, nil)
+	case selfApprovalWithExtraLGTM:
+		add(msgHelpSelfApprovalExtraLGTM, msgData{"Count": cfg.SelfApprovalExtraLgtm})
+	}
+
+	if cfg.Size.ExtraLgtmForXL > 0 {
+		add(msgHelpSizeExtraLGTM, msgData{"Count": cfg.Size.ExtraLgtmForXL})
+	}
+
+	if cfg.CheckDCO {
+		add(msgHelpDCO, nil)
+	}
+
+	if len(cfg.FreezeFile) > 0 {
+		add(msgHelpNotFrozen, nil)
+	}
+
+	if d := cfg.ReviewPeriod.durationFor(branch); d > 0 {
+		add(msgHelpReviewPeriod, msgData{"Duration": d.String(), "SinceLastPush": cfg.ReviewPeriod.SinceLastPush})
+	}
+
+	if p := &cfg.PRPolicy; p.TitlePattern != "" || len(p.RequiredSections) > 0 || p.MinDescriptionLength > 0 ||
+		p.RequireLinkedIssue || len(p.RequireLinkedIssueForLabels) > 0 {
+		add(msgHelpPRPolicy, nil)
+	}
+
+	if cfg.CommitRules.enabled() {
+		add(msgHelpCommitRules, msgData{"Squash": cfg.CommitRules.SquashInstead})
+	}
+
+	for i := range cfg.ProtectedPaths {
+		p := &cfg.ProtectedPaths[i]
+		add(msgHelpProtectedPath, msgData{
+			"Paths":     strings.Join(p.Paths, ", "),
+			"Approvers": strings.Join(p.Approvers, ", "),
+		})
+	}
+
+	if cfg.NativeReview.RequireTestPass {
+		add(msgHelpTestPass, nil)
+	}
+
+	return r
+}
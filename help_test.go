@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMergeConditions(t *testing.T) {
+	cfg := &botConfig{
+		LgtmCountsRequired:    2,
+		MissingLabelsForMerge: []string{"wip"},
+		SelfApprovalPolicy:    selfApprovalWithExtraLGTM,
+		CheckDCO:              true,
+		ReviewPeriod:          reviewPeriodConfig{MinOpenDuration: "24h", SinceLastPush: true},
+		PRPolicy:              prPolicyConfig{RequireLinkedIssue: true},
+		CommitRules:           commitRulesConfig{MaxCommits: 1, SquashInstead: true},
+		Size:                  sizeConfig{Enabled: true, ExtraLgtmForXL: 1},
+		ProtectedPaths:        []protectedPath{{Paths: []string{"OWNERS"}, Approvers: []string{"alice"}}},
+		NativeReview:          nativeReviewConfig{RequireTestPass: true},
+	}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	v := strings.Join(mergeConditions(cfg, "master"), "\n")
+
+	for _, s := range []string{
+		"2 ***lgtm***", "wip", "approved by its author", "size/XL", "signed off",
+		"24h0m0s since the last push", "PR policy", "squashed", "OWNERS", "alice", "testers",
+	} {
+		if !strings.Contains(v, s) {
+			t.Errorf("%q is missing in the conditions:\n%s", s, v)
+		}
+	}
+
+	cfg = &botConfig{}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the lgtm and labels are required by default.
+	if n := len(mergeConditions(cfg, "master")); n != 2 {
+		t.Errorf("there are %d conditions by default", n)
+	}
+}
+
+func TestCommandSpecPermissionFor(t *testing.T) {
+	spec, _ := newCommandRegistry().find(command{tokens: []string{"lgtm", "cancel"}})
+	if spec == nil {
+		t.Fatal("lgtm cancel is not registered")
+	}
+
+	if p := spec.permissionFor(nil); p != permissionAuthorOrCollaborator {
+		t.Errorf("permission = %d", p)
+	}
+
+	if p := spec.permissionFor([]string{"@alice"}); p != permissionCollaborator {
+		t.Errorf("permission with args = %d", p)
+	}
+}
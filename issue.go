@@ -0,0 +1,140 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/sirupsen/logrus"
+)
+
+// regIssueRef matches the references of gitee issue, such as #I1ABCD, org/repo#I1ABCD
+// and https://gitee.com/org/repo/issues/I1ABCD. The number of gitee issue starts with I.
+var regIssueRef = regexp.MustCompile(
+	`(?:https://gitee\.com/([\w.-]+)/([\w.-]+)/issues/|(?:\b([\w.-]+)/([\w.-]+))?#)(I[0-9A-Z]+)\b`,
+)
+
+// regClosingIssueRef matches the references of issue following the closing keywords, such as Fixes #I1ABCD.
+var regClosingIssueRef = regexp.MustCompile(
+	`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+((?:https://gitee\.com/[\w.-]+/[\w.-]+/issues/|(?:[\w.-]+/[\w.-]+)?#)I[0-9A-Z]+)\b`,
+)
+
+type issueRef struct {
+	org    string
+	repo   string
+	number string
+}
+
+func (r issueRef) String() string {
+	return r.org + "/" + r.repo + "#" + r.number
+}
+
+// parseIssueRefs returns the issues referenced in the text.
+// The issue without org and repo belongs to the repo of PR.
+func parseIssueRefs(text, org, repo string) []issueRef {
+	var r []issueRef
+
+	seen := map[string]bool{}
+
+	for _, m := range regIssueRef.FindAllStringSubmatch(text, -1) {
+		ref := issueRef{org: org, repo: repo, number: m[5]}
+
+		switch {
+		case m[1] != "":
+			ref.org, ref.repo = m[1], m[2]
+		case m[3] != "":
+			ref.org, ref.repo = m[3], m[4]
+		}
+
+		if k := strings.ToLower(ref.String()); !seen[k] {
+			seen[k] = true
+			r = append(r, ref)
+		}
+	}
+
+	return r
+}
+
+// parseClosingIssueRefs returns the issues which the text claims to close.
+func parseClosingIssueRefs(text, org, repo string) []issueRef {
+	var v []string
+	for _, m := range regClosingIssueRef.FindAllStringSubmatch(text, -1) {
+		v = append(v, m[1])
+	}
+
+	return parseIssueRefs(strings.Join(v, " "), org, repo)
+}
+
+// closeLinkedIssues comments on and closes the issues which the merged PR
+// claims to close in its description or commits.
+func (m *mergeHelper) closeLinkedIssues(log *logrus.Entry) {
+	text := []string{m.pr.Body}
+
+	commits, err := m.cli.GetPRCommits(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		log.WithError(err).Error("get the commits of pr")
+	}
+
+	for i := range commits {
+		if c := commits[i].Commit; c != nil {
+			text = append(text, c.Message)
+		}
+	}
+
+	state := m.cfg.LinkedIssues.State
+	comment := m.cfg.message(commentIssueClosedByPR, msgData{"URL": m.pr.HtmlUrl})
+
+	for _, ref := range parseClosingIssueRefs(strings.Join(text, "\n"), m.org, m.repo) {
+		if ok, err := m.canCloseIssue(ref); !ok {
+			if err != nil {
+				log.WithError(err).Errorf("check the permission on issue: %s", ref)
+			} else {
+				log.Infof("the author of pr has no permission to close issue: %s", ref)
+			}
+
+			continue
+		}
+
+		issue, err := m.cli.GetIssue(ref.org, ref.repo, ref.number)
+		if err != nil {
+			log.WithError(err).Errorf("get issue: %s", ref)
+
+			continue
+		}
+
+		if issue.State == state {
+			continue
+		}
+
+		_, err = m.cli.UpdateIssue(ref.org, ref.number, sdk.IssueUpdateParam{Repo: ref.repo, State: state})
+		if err != nil {
+			log.WithError(err).Errorf("change the state of issue: %s", ref)
+
+			continue
+		}
+
+		if err := m.cli.CreateIssueComment(ref.org, ref.repo, ref.number, comment); err != nil {
+			log.WithError(err).Errorf("comment on issue: %s", ref)
+		}
+	}
+}
+
+// canCloseIssue checks whether the issue can be closed by the PR. The issue of other repos
+// can be closed only if the author of PR can write to that repo, otherwise anyone could
+// close any issue by referring to it in a PR of their own repo.
+func (m *mergeHelper) canCloseIssue(ref issueRef) (bool, error) {
+	if strings.EqualFold(ref.org, m.org) && strings.EqualFold(ref.repo, m.repo) {
+		return true, nil
+	}
+
+	if m.pr.User == nil || m.pr.User.Login == "" {
+		return false, nil
+	}
+
+	p, err := m.cli.GetUserPermissionsOfRepo(ref.org, ref.repo, strings.ToLower(m.pr.User.Login))
+	if err != nil {
+		return false, err
+	}
+
+	return p.Permission == "admin" || p.Permission == "write", nil
+}
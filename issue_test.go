@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseClosingIssueRefs(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "no keyword", text: "see #I1ABCD"},
+		{name: "same repo", text: "Fixes #I1ABCD", want: []string{"org/repo#I1ABCD"}},
+		{name: "other repo", text: "closes: foo/bar#I2XYZ", want: []string{"foo/bar#I2XYZ"}},
+		{
+			name: "url",
+			text: "Resolved https://gitee.com/foo/bar/issues/I3AAA",
+			want: []string{"foo/bar#I3AAA"},
+		},
+		{
+			name: "duplicate and mixed",
+			text: "fix #I1ABCD\nFIXES #I1ABCD, refer #I9ZZZ and close #I2B",
+			want: []string{"org/repo#I1ABCD", "org/repo#I2B"},
+		},
+		{name: "not issue of gitee", text: "fixes #123"},
+	}
+
+	for _, tc := range cases {
+		refs := parseClosingIssueRefs(tc.text, "org", "repo")
+
+		if len(refs) != len(tc.want) {
+			t.Errorf("%s: refs = %v, want %v", tc.name, refs, tc.want)
+
+			continue
+		}
+
+		for i := range refs {
+			if refs[i].String() != tc.want[i] {
+				t.Errorf("%s: refs = %v, want %v", tc.name, refs, tc.want)
+			}
+		}
+	}
+}
+
+func TestLinkedIssuesConfigValidate(t *testing.T) {
+	for state, valid := range map[string]bool{"closed": true, "rejected": true, "done": false} {
+		c := linkedIssuesConfig{State: state}
+		if err := c.validate(); (err == nil) != valid {
+			t.Errorf("%s: err = %v", state, err)
+		}
+	}
+}
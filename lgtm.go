@@ -2,10 +2,8 @@ package main
 
 import (
 	"fmt"
-	"regexp"
 	"strings"
 
-	sdk "gitee.com/openeuler/go-gitee/gitee"
 	"github.com/opensourceways/community-robot-lib/giteeclient"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -15,61 +13,27 @@ const (
 	// the gitee platform limits the maximum length of label to 20.
 	labelLenLimit = 20
 	lgtmLabel     = "lgtm"
-
-	commentAddLGTMBySelf            = "***lgtm*** can not be added in your self-own pull request. :astonished:"
-	commentClearLabel               = `New code changes of pr are detected and remove these labels ***%s***. :flushed: `
-	commentNoPermissionForLgtmLabel = `Thanks for your review, ***%s***, your opinion is very important to us.:wave:
-The maintainers will consider your advice carefully.`
-	commentNoPermissionForLabel = `
-***@%s*** has no permission to %s ***%s*** label in this pull request. :astonished:
-Please contact to the collaborators in this repository.`
-	commentAddLabel = `***%s*** was added to this pull request by: ***%s***. :wave: 
-**NOTE:** If this pull request is not merged while all conditions are met, comment "/check-pr" to try again. :smile: `
-	commentRemovedLabel = `***%s*** was removed in this pull request by: ***%s***. :flushed: `
-)
-
-var (
-	regAddLgtm    = regexp.MustCompile(`(?mi)^/lgtm\s*$`)
-	regRemoveLgtm = regexp.MustCompile(`(?mi)^/lgtm cancel\s*$`)
 )
 
-func (bot *robot) handleLGTM(e *sdk.NoteEvent, cfg *botConfig, log *logrus.Entry) error {
-	ne := giteeclient.NewPRNoteEvent(e)
-
-	if !ne.IsPullRequest() || !ne.IsPROpen() || !ne.IsCreatingCommentEvent() {
-		return nil
-	}
-
-	if regAddLgtm.MatchString(ne.GetComment()) {
-		return bot.addLGTM(cfg, ne, log)
+// checkSelfLGTM returns the reply if the commenter is the author of PR or its commits.
+func (bot *robot) checkSelfLGTM(cfg *botConfig, pr giteeclient.PRInfo, commenter, email string) (string, error) {
+	if pr.Author == commenter {
+		return cfg.message(commentAddLGTMBySelf, nil), nil
 	}
 
-	if regRemoveLgtm.MatchString(ne.GetComment()) {
-		return bot.removeLGTM(cfg, ne, log)
+	isAuthor, err := bot.isCommitAuthor(cfg, pr, commenter, email)
+	if err != nil || !isAuthor {
+		return "", err
 	}
 
-	return nil
+	return cfg.message(commentAddLGTMByCommitAuthor, nil), nil
 }
 
+// addLGTM adds the lgtm label for the commenter who has passed checkSelfLGTM.
 func (bot *robot) addLGTM(cfg *botConfig, e giteeclient.PRNoteEvent, log *logrus.Entry) error {
 	pr := e.GetPRInfo()
 	org, repo, number := pr.Org, pr.Repo, pr.Number
-
 	commenter := e.GetCommenter()
-	if pr.Author == commenter {
-		return bot.cli.CreatePRComment(org, repo, number, commentAddLGTMBySelf)
-	}
-
-	v, err := bot.hasPermission(commenter, pr, log)
-	if err != nil {
-		return err
-	}
-	if !v {
-		return bot.cli.CreatePRComment(
-			org, repo, number,
-			fmt.Sprintf(commentNoPermissionForLgtmLabel, commenter),
-		)
-	}
 
 	label := genLGTMLabel(commenter, cfg.LgtmCountsRequired)
 	if label != lgtmLabel {
@@ -82,8 +46,9 @@ func (bot *robot) addLGTM(cfg *botConfig, e giteeclient.PRNoteEvent, log *logrus
 		return err
 	}
 
-	err = bot.cli.CreatePRComment(
-		org, repo, number, fmt.Sprintf(commentAddLabel, label, commenter),
+	err := bot.notify(
+		org, repo, number, cfg,
+		cfg.message(commentAddLabel, msgData{"Label": label, "Commenter": commenter}), log,
 	)
 	if err != nil {
 		log.Error(err)
@@ -97,34 +62,91 @@ func (bot *robot) removeLGTM(cfg *botConfig, e giteeclient.PRNoteEvent, log *log
 	org, repo, number := pr.Org, pr.Repo, pr.Number
 
 	if commenter := e.GetCommenter(); pr.Author != commenter {
-		v, err := bot.hasPermission(commenter, pr, log)
-		if err != nil {
-			return err
-		}
-		if !v {
-			return bot.cli.CreatePRComment(org, repo, number, fmt.Sprintf(
-				commentNoPermissionForLabel, commenter, "remove", lgtmLabel,
-			))
-		}
-
 		l := genLGTMLabel(commenter, cfg.LgtmCountsRequired)
-		if err = bot.cli.RemovePRLabel(org, repo, number, l); err != nil {
+		if err := bot.cli.RemovePRLabel(org, repo, number, l); err != nil {
 			return err
 		}
 
-		return bot.cli.CreatePRComment(
-			org, repo, number, fmt.Sprintf(commentRemovedLabel, l, commenter),
+		return bot.notify(
+			org, repo, number, cfg,
+			cfg.message(commentRemovedLabel, msgData{"Label": l, "Commenter": commenter}), log,
 		)
 	}
 
 	// the author of pr can remove all of lgtm[-login name] kind labels
 	if v := getLGTMLabelsOnPR(pr.Labels); len(v) > 0 {
-		return bot.cli.RemovePRLabels(org, repo, number, v)
+		if err := bot.cli.RemovePRLabels(org, repo, number, v); err != nil || !cfg.StatusComment {
+			return err
+		}
+
+		return bot.updateStatusComment(org, repo, number, cfg, log)
 	}
 
 	return nil
 }
 
+// revokeLGTM removes the lgtm of another reviewer, it can be used by the collaborators
+// which is checked by the argsPermission of /lgtm cancel.
+func (bot *robot) revokeLGTM(cfg *botConfig, e giteeclient.PRNoteEvent, reviewer string, log *logrus.Entry) error {
+	pr := e.GetPRInfo()
+	org, repo, number := pr.Org, pr.Repo, pr.Number
+
+	commenter := e.GetCommenter()
+	if strings.EqualFold(commenter, reviewer) {
+		return bot.removeLGTM(cfg, e, log)
+	}
+
+	// the lgtm label is shared by all the reviewers, so whose lgtm it is can't be told.
+	if cfg.LgtmCountsRequired <= 1 {
+		return bot.cli.CreatePRComment(org, repo, number, cfg.message(
+			commentRevokeSharedLGTM, msgData{"Commenter": commenter},
+		))
+	}
+
+	l := genLGTMLabel(reviewer, cfg.LgtmCountsRequired)
+	if !pr.Labels.Has(l) {
+		return bot.cli.CreatePRComment(org, repo, number, cfg.message(
+			commentLGTMNotFound, msgData{"Commenter": commenter, "Reviewer": reviewer},
+		))
+	}
+
+	if err := bot.cli.RemovePRLabel(org, repo, number, l); err != nil {
+		return err
+	}
+
+	// the audit comment is always posted even if the status comment is enabled.
+	err := bot.cli.CreatePRComment(org, repo, number, cfg.message(
+		commentRevokedLGTM, msgData{"Label": l, "Commenter": commenter, "Reviewer": reviewer},
+	))
+	if err != nil || !cfg.StatusComment {
+		return err
+	}
+
+	return bot.updateStatusComment(org, repo, number, cfg, log)
+}
+
+// isCommitAuthor checks whether the commenter contributes to the commits of PR
+// according to the self lgtm policy of repo. The email of commenter is optional.
+func (bot *robot) isCommitAuthor(cfg *botConfig, pr giteeclient.PRInfo, commenter, email string) (bool, error) {
+	p := cfg.SelfLGTMPolicy
+	if p != selfLGTMCommitAuthors && p != selfLGTMCommitAuthorsAndCommitters {
+		return false, nil
+	}
+
+	commits, err := bot.cli.GetPRCommits(pr.Org, pr.Repo, pr.Number)
+	if err != nil {
+		return false, err
+	}
+
+	for _, v := range getCommitAuthors(commits, p == selfLGTMCommitAuthorsAndCommitters) {
+		if v.is(commenter, email) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func (bot *robot) createLabelIfNeed(org, repo, label string) error {
 	repoLabels, err := bot.cli.GetRepoLabels(org, repo)
 	if err != nil {
@@ -153,6 +175,19 @@ func genLGTMLabel(commenter string, lgtmCount uint) string {
 	return l
 }
 
+// countLGTM returns the number of lgtm the PR gets.
+func countLGTM(labels sets.String, cfg *botConfig) uint {
+	if cfg.LgtmCountsRequired <= 1 {
+		if labels.Has(lgtmLabel) {
+			return 1
+		}
+
+		return 0
+	}
+
+	return uint(len(getLGTMLabelsOnPR(labels)))
+}
+
 func getLGTMLabelsOnPR(labels sets.String) []string {
 	var r []string
 
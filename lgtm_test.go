@@ -0,0 +1,110 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+)
+
+func TestRevokeLGTM(t *testing.T) {
+	cases := []struct {
+		name      string
+		commenter string
+		comment   string
+		count     uint
+		labels    []string
+		removed   []string
+		reply     string
+	}{
+		{
+			name:      "revoked",
+			commenter: "alice",
+			comment:   "/lgtm cancel @bob",
+			count:     2,
+			labels:    []string{"lgtm-bob", "lgtm-carol"},
+			removed:   []string{"lgtm-bob"},
+			reply:     "was revoked",
+		},
+		{
+			name:      "case insensitive",
+			commenter: "alice",
+			comment:   "/lgtm cancel @Bob",
+			count:     2,
+			labels:    []string{"lgtm-bob"},
+			removed:   []string{"lgtm-bob"},
+			reply:     "was revoked",
+		},
+		{
+			name:      "own lgtm",
+			commenter: "bob",
+			comment:   "/lgtm cancel @bob",
+			count:     2,
+			labels:    []string{"lgtm-bob", "lgtm-carol"},
+			removed:   []string{"lgtm-bob"},
+		},
+		{
+			name:      "not found",
+			commenter: "alice",
+			comment:   "/lgtm cancel @carol",
+			count:     2,
+			labels:    []string{"lgtm-bob"},
+			reply:     "there is no lgtm",
+		},
+		{
+			name:      "shared lgtm",
+			commenter: "alice",
+			comment:   "/lgtm cancel @bob",
+			count:     1,
+			labels:    []string{lgtmLabel},
+			reply:     "can't be revoked",
+		},
+		{
+			// the author can cancel the lgtm but can't revoke the one of others.
+			name:      "no permission",
+			commenter: "author",
+			comment:   "/lgtm cancel @bob",
+			count:     2,
+			labels:    []string{"lgtm-bob"},
+			reply:     "has no permission",
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{LgtmCountsRequired: tc.count}
+		cfg.setDefault()
+		if err := cfg.validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		cli := newFakeClient()
+		cli.permissions = map[string]string{"alice": "write", "bob": "write"}
+		bot := newTestRobot(cli)
+
+		spec, args := bot.commands.find(parseCommands(tc.comment)[0])
+		if spec == nil {
+			t.Fatalf("%s: command is not found", tc.name)
+		}
+
+		e := newTestNoteEvent(tc.commenter, tc.comment, tc.labels...)
+		c := &commandContext{
+			event: giteeclient.NewPRNoteEvent(e),
+			note:  e,
+			cfg:   cfg,
+			log:   newTestLog(),
+			args:  args,
+		}
+
+		if err := bot.runCommand(spec, c); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if strings.Join(cli.removed, ",") != strings.Join(tc.removed, ",") {
+			t.Errorf("%s: removed = %v, want %v", tc.name, cli.removed, tc.removed)
+		}
+
+		if tc.reply != "" && (len(cli.created) != 1 || !strings.Contains(cli.created[0], tc.reply)) {
+			t.Errorf("%s: comments = %q, want %q", tc.name, cli.created, tc.reply)
+		}
+	}
+}
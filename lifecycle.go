@@ -0,0 +1,130 @@
+package main
+
+import (
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	staleLabel  = "stale"
+	frozenLabel = "lifecycle/frozen"
+)
+
+// removeStale can be used by anyone.
+func (bot *robot) removeStale(e giteeclient.PRNoteEvent, cfg *botConfig) error {
+	pr := e.GetPRInfo()
+	if !pr.Labels.Has(staleLabel) {
+		return nil
+	}
+
+	if err := bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, staleLabel); err != nil {
+		return err
+	}
+
+	return bot.cli.CreatePRComment(
+		pr.Org, pr.Repo, pr.Number,
+		cfg.message(commentRemovedLabel, msgData{"Label": staleLabel, "Commenter": e.GetCommenter()}),
+	)
+}
+
+func (bot *robot) freezeLifecycle(e giteeclient.PRNoteEvent, cfg *botConfig, log *logrus.Entry) error {
+	pr := e.GetPRInfo()
+	commenter := e.GetCommenter()
+
+	if err := bot.createLabelIfNeed(pr.Org, pr.Repo, frozenLabel); err != nil {
+		log.WithError(err).Errorf("create repo label: %s", frozenLabel)
+	}
+
+	if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, frozenLabel); err != nil {
+		return err
+	}
+
+	if pr.Labels.Has(staleLabel) {
+		if err := bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, staleLabel); err != nil {
+			log.WithError(err).Errorf("remove label: %s", staleLabel)
+		}
+	}
+
+	return bot.cli.CreatePRComment(
+		pr.Org, pr.Repo, pr.Number,
+		cfg.message(commentAddLabel, msgData{"Label": frozenLabel, "Commenter": commenter}),
+	)
+}
+
+func (bot *robot) unfreezeLifecycle(e giteeclient.PRNoteEvent, cfg *botConfig) error {
+	pr := e.GetPRInfo()
+	commenter := e.GetCommenter()
+
+	if err := bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, frozenLabel); err != nil {
+		return err
+	}
+
+	return bot.cli.CreatePRComment(
+		pr.Org, pr.Repo, pr.Number,
+		cfg.message(commentRemovedLabel, msgData{"Label": frozenLabel, "Commenter": commenter}),
+	)
+}
+
+// handleStale is called by the reconciler periodically. It marks the inactive PR
+// as stale and closes it if it is still inactive after the grace period.
+func (bot *robot) handleStale(org, repo string, pr *sdk.PullRequest, cfg *botConfig, log *logrus.Entry) error {
+	sc := &cfg.Stale
+	if sc.DaysUntilStale == 0 {
+		return nil
+	}
+
+	labels := getPRLabels(pr)
+	if labels.Has(frozenLabel) || labels.HasAny(sc.ExemptLabels...) {
+		return nil
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339, pr.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	inactive := time.Since(updatedAt)
+
+	// the stale label will refresh the updated time of PR,
+	// so the inactive time is counted from then on.
+	if labels.Has(staleLabel) {
+		if sc.DaysUntilClose == 0 || inactive < days(sc.DaysUntilClose) {
+			return nil
+		}
+
+		err := bot.cli.CreatePRComment(
+			org, repo, pr.Number,
+			cfg.message(commentCloseStale, msgData{"Label": staleLabel, "DaysUntilClose": sc.DaysUntilClose}),
+		)
+		if err != nil {
+			log.Error(err)
+		}
+
+		return bot.cli.ClosePR(org, repo, pr.Number)
+	}
+
+	if inactive < days(sc.DaysUntilStale) {
+		return nil
+	}
+
+	if err := bot.createLabelIfNeed(org, repo, staleLabel); err != nil {
+		log.WithError(err).Errorf("create repo label: %s", staleLabel)
+	}
+
+	if err := bot.cli.AddPRLabel(org, repo, pr.Number, staleLabel); err != nil {
+		return err
+	}
+
+	return bot.cli.CreatePRComment(org, repo, pr.Number, cfg.message(commentMarkStale, msgData{
+		"Label":          staleLabel,
+		"DaysUntilStale": sc.DaysUntilStale,
+		"DaysUntilClose": sc.DaysUntilClose,
+	}))
+}
+
+func days(n uint) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestHandleStale(t *testing.T) {
+	cases := []struct {
+		name     string
+		inactive uint
+		labels   []string
+		stale    staleConfig
+		added    []string
+		closed   bool
+		comments int
+	}{
+		{
+			name:     "disabled",
+			inactive: 100,
+		},
+		{
+			name:     "active",
+			inactive: 10,
+			stale:    staleConfig{DaysUntilStale: 30, DaysUntilClose: 7},
+		},
+		{
+			name:     "marked as stale",
+			inactive: 31,
+			stale:    staleConfig{DaysUntilStale: 30, DaysUntilClose: 7},
+			added:    []string{staleLabel},
+			comments: 1,
+		},
+		{
+			name:     "stale in grace period",
+			inactive: 5,
+			labels:   []string{staleLabel},
+			stale:    staleConfig{DaysUntilStale: 30, DaysUntilClose: 7},
+		},
+		{
+			name:     "closed",
+			inactive: 8,
+			labels:   []string{staleLabel},
+			stale:    staleConfig{DaysUntilStale: 30, DaysUntilClose: 7},
+			closed:   true,
+			comments: 1,
+		},
+		{
+			name:     "never closed",
+			inactive: 100,
+			labels:   []string{staleLabel},
+			stale:    staleConfig{DaysUntilStale: 30},
+		},
+		{
+			name:     "frozen",
+			inactive: 100,
+			labels:   []string{frozenLabel},
+			stale:    staleConfig{DaysUntilStale: 30, DaysUntilClose: 7},
+		},
+		{
+			name:     "exempt",
+			inactive: 100,
+			labels:   []string{"kind/feature"},
+			stale:    staleConfig{DaysUntilStale: 30, DaysUntilClose: 7, ExemptLabels: []string{"kind/feature"}},
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{Stale: tc.stale}
+		cfg.setDefault()
+
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		pr := &sdk.PullRequest{
+			Number:    1,
+			State:     "open",
+			UpdatedAt: time.Now().Add(-days(tc.inactive)).Format(time.RFC3339),
+		}
+		for _, l := range tc.labels {
+			pr.Labels = append(pr.Labels, sdk.Label{Name: l})
+		}
+
+		if err := bot.handleStale("org", "repo", pr, cfg, newTestLog()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if strings.Join(cli.added, ",") != strings.Join(tc.added, ",") {
+			t.Errorf("%s: added = %v, want %v", tc.name, cli.added, tc.added)
+		}
+
+		if cli.closed != tc.closed {
+			t.Errorf("%s: closed = %t, want %t", tc.name, cli.closed, tc.closed)
+		}
+
+		if len(cli.created) != tc.comments {
+			t.Errorf("%s: comments = %q", tc.name, cli.created)
+		}
+	}
+}
@@ -2,10 +2,11 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"net/http"
 	"net/url"
 	"os"
 
-	"github.com/opensourceways/community-robot-lib/giteeclient"
 	libplugin "github.com/opensourceways/community-robot-lib/giteeplugin"
 	"github.com/opensourceways/community-robot-lib/logrusutil"
 	liboptions "github.com/opensourceways/community-robot-lib/options"
@@ -15,10 +16,15 @@ import (
 )
 
 type options struct {
-	plugin        liboptions.PluginOptions
-	gitee         liboptions.GiteeOptions
-	cacheEndpoint string
-	maxRetries    int
+	plugin         liboptions.PluginOptions
+	gitee          liboptions.GiteeOptions
+	client         clientOptions
+	reconciler     reconcilerOptions
+	cherryPick     cherryPickOptions
+	cacheEndpoint  string
+	maxRetries     int
+	dedupCacheSize int
+	dedupStorePath string
 }
 
 func (o *options) Validate() error {
@@ -26,6 +32,22 @@ func (o *options) Validate() error {
 		return err
 	}
 
+	if o.dedupCacheSize <= 0 {
+		return fmt.Errorf("dedup-cache-size must be positive")
+	}
+
+	if err := o.client.validate(); err != nil {
+		return err
+	}
+
+	if err := o.reconciler.validate(); err != nil {
+		return err
+	}
+
+	if err := o.cherryPick.validate(); err != nil {
+		return err
+	}
+
 	if err := o.plugin.Validate(); err != nil {
 		return err
 	}
@@ -38,8 +60,13 @@ func gatherOptions(fs *flag.FlagSet, args ...string) options {
 
 	o.gitee.AddFlags(fs)
 	o.plugin.AddFlags(fs)
+	o.client.addFlags(fs)
+	o.reconciler.addFlags(fs)
+	o.cherryPick.addFlags(fs)
 	fs.StringVar(&o.cacheEndpoint, "cache-endpoint", "", "The endpoint of repo file cache")
 	fs.IntVar(&o.maxRetries, "max-retries", 3, "The number of failed retry attempts to call the cache api")
+	fs.IntVar(&o.dedupCacheSize, "dedup-cache-size", 10000, "The maximum number of handled events to remember for deduplicating redeliveries")
+	fs.StringVar(&o.dedupStorePath, "dedup-store-path", "", "The path of file to persist handled events, it is disabled when empty")
 
 	_ = fs.Parse(args)
 
@@ -59,12 +86,37 @@ func main() {
 		logrus.WithError(err).Fatal("Error starting secret agent.")
 	}
 
-	c := giteeclient.NewClient(secretAgent.GetTokenGenerator(o.gitee.TokenPath))
+	getToken := secretAgent.GetTokenGenerator(o.gitee.TokenPath)
+
+	hc := &http.Client{Transport: newRateLimitTransport(http.DefaultTransport, o.client)}
+
+	c := newRetryClient(newGiteeClient(getToken, hc), o.client)
 	s := cache.NewSDK(o.cacheEndpoint, o.maxRetries)
 
-	p := newRobot(c, s)
+	d, err := newEventDeduplicator(o.dedupCacheSize, o.dedupStorePath)
+	if err != nil {
+		logrus.WithError(err).Fatal("Error starting event deduplicator.")
+	}
+
+	var picker *cherryPicker
+	if o.cherryPick.workDir != "" {
+		picker = newCherryPicker(o.cherryPick, getToken)
+		picker.start()
+	}
+
+	p := newRobot(c, s, d, picker)
+
+	r := newReconciler(p, o.reconciler)
+	r.start()
 
 	libplugin.Run(p, o.plugin)
 
+	r.stop()
+
+	if picker != nil {
+		picker.stop()
+	}
+
+	d.stop()
 	secretAgent.Stop()
 }
@@ -3,8 +3,9 @@ package main
 import (
 	"encoding/base64"
 	"fmt"
-	"regexp"
+	"sort"
 	"strings"
+	"time"
 
 	sdk "gitee.com/openeuler/go-gitee/gitee"
 	"github.com/opensourceways/community-robot-lib/giteeclient"
@@ -13,29 +14,6 @@ import (
 	"sigs.k8s.io/yaml"
 )
 
-const (
-	msgPRConflicts        = "PR conflicts to the target branch."
-	msgMissingLabels      = "PR does not have these lables: %s"
-	msgInvalidLabels      = "PR should remove these labels: %s"
-	msgNotEnoughLGTMLabel = "PR needs %d lgtm labels and now gets %d"
-	msgFrozenWithOwner    = "The target branch of PR has been frozen and it can be merge only by branch owners: %s"
-)
-
-var regCheckPr = regexp.MustCompile(`(?mi)^/check-pr\s*$`)
-
-func (bot *robot) handleCheckPR(e *sdk.NoteEvent, cfg *botConfig, log *logrus.Entry) error {
-	ne := giteeclient.NewPRNoteEvent(e)
-
-	if !ne.IsPullRequest() ||
-		!ne.IsPROpen() ||
-		!ne.IsCreatingCommentEvent() ||
-		!regCheckPr.MatchString(ne.GetComment()) {
-		return nil
-	}
-
-	return bot.tryMerge(ne, cfg, true, log)
-}
-
 func (bot *robot) tryMerge(e giteeclient.PRNoteEvent, cfg *botConfig, addComment bool, log *logrus.Entry) error {
 	org, repo := e.GetOrgRep()
 
@@ -44,25 +22,34 @@ func (bot *robot) tryMerge(e giteeclient.PRNoteEvent, cfg *botConfig, addComment
 		org:     org,
 		repo:    repo,
 		cli:     bot.cli,
+		pushes:  bot.pushes,
 		pr:      e.GetPullRequest(),
 		trigger: e.GetCommenter(),
 	}
 
-	if r, ok := h.canMerge(log); !ok {
-		if len(r) > 0 && addComment {
-			return bot.cli.CreatePRComment(
-				org, repo, e.GetPRNumber(),
-				fmt.Sprintf(
-					"@%s , this pr is not mergeable and the reasons are below:\n%s",
-					e.GetCommenter(), strings.Join(r, "\n"),
-				),
-			)
+	if checks, ok := h.canMerge(log); !ok {
+		if !h.reviewEndsAt.IsZero() {
+			bot.scheduleMerge(org, repo, e.GetPRNumber(), h.reviewEndsAt, cfg, log)
 		}
 
-		return nil
+		if !addComment {
+			return nil
+		}
+
+		if cfg.StatusComment {
+			return bot.updateStatusComment(org, repo, e.GetPRNumber(), cfg, log)
+		}
+
+		return bot.cli.CreatePRComment(
+			org, repo, e.GetPRNumber(),
+			cfg.message(commentNotMergeable, msgData{
+				"Commenter": e.GetCommenter(),
+				"Checks":    tableRows(checks),
+			}),
+		)
 	}
 
-	return h.merge()
+	return h.merge(log)
 }
 
 func (bot *robot) handleLabelUpdate(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
@@ -73,15 +60,24 @@ func (bot *robot) handleLabelUpdate(e *sdk.PullRequestEvent, cfg *botConfig, log
 	org, repo := giteeclient.GetOwnerAndRepoByPREvent(e)
 
 	h := mergeHelper{
-		cfg:  cfg,
-		org:  org,
-		repo: repo,
-		cli:  bot.cli,
-		pr:   e.GetPullRequest(),
+		cfg:    cfg,
+		org:    org,
+		repo:   repo,
+		cli:    bot.cli,
+		pushes: bot.pushes,
+		pr:     e.GetPullRequest(),
 	}
 
 	if _, ok := h.canMerge(log); ok {
-		return h.merge()
+		return h.merge(log)
+	}
+
+	if !h.reviewEndsAt.IsZero() {
+		bot.scheduleMerge(org, repo, h.pr.Number, h.reviewEndsAt, cfg, log)
+	}
+
+	if cfg.StatusComment {
+		return bot.updateStatusComment(org, repo, h.pr.Number, cfg, log)
 	}
 
 	return nil
@@ -96,12 +92,25 @@ type mergeHelper struct {
 	trigger string
 
 	cli iClient
+
+	// pushes is used to get the time when the PR was pushed lastly.
+	pushes *pushTracker
+
+	// reviewEndsAt is the time when the review period of PR elapses.
+	// It is set by canMerge if the period has not elapsed.
+	reviewEndsAt time.Time
+
+	// squash is set by canMerge if the PR should be squashed instead of
+	// being blocked because of its commits.
+	squash bool
 }
 
-func (m *mergeHelper) merge() error {
+func (m *mergeHelper) merge(log *logrus.Entry) error {
 	number := m.pr.Number
 
-	if m.pr.NeedReview || m.pr.NeedTest {
+	// the review and test on gitee are checked by canMerge if the native review is used,
+	// otherwise they are skipped, because the bot takes charge of the review.
+	if !m.cfg.NativeReview.enabled() && (m.pr.NeedReview || m.pr.NeedTest) {
 		v := int32(0)
 		p := sdk.PullRequestUpdateParam{
 			AssigneesNumber: &v,
@@ -113,48 +122,116 @@ func (m *mergeHelper) merge() error {
 		}
 	}
 
-	return m.cli.MergePR(
+	method := m.cfg.MergeMethod
+	if m.squash {
+		method = mergeMethodSquash
+	}
+
+	err := m.cli.MergePR(
 		m.org, m.repo, number,
 		sdk.PullRequestMergePutParam{
-			MergeMethod: string(m.cfg.MergeMethod),
+			MergeMethod: string(method),
 		},
 	)
+	if err != nil {
+		return err
+	}
+
+	// the PR has been merged, so the failure of closing issues is not returned.
+	if m.cfg.LinkedIssues.CloseOnMerge {
+		m.closeLinkedIssues(log)
+	}
+
+	return nil
 }
 
-func (m *mergeHelper) canMerge(log *logrus.Entry) ([]string, bool) {
-	if !m.pr.GetMergeable() {
-		return []string{msgPRConflicts}, false
+// mergeCheck is the result of checking a condition to merge PR.
+type mergeCheck struct {
+	Name   string
+	Passed bool
+	Detail string
+}
+
+// GFM splits the cells of table on | even inside the code spans, such as the patterns
+// of title or commit message, and a cell can't contain line breaks.
+var tableCellEscaper = strings.NewReplacer("|", `\|`, "\r\n", "<br/>", "\n", "<br/>")
+
+// tableRows escapes the checks to be rendered as the rows of markdown table.
+func tableRows(checks []mergeCheck) []mergeCheck {
+	r := make([]mergeCheck, len(checks))
+
+	for i, c := range checks {
+		c.Name = tableCellEscaper.Replace(c.Name)
+		c.Detail = tableCellEscaper.Replace(c.Detail)
+		r[i] = c
 	}
 
+	return r
+}
+
+// canMerge checks all the conditions independently, so that all the
+// unsatisfied ones can be reported at once.
+func (m *mergeHelper) canMerge(log *logrus.Entry) ([]mergeCheck, bool) {
+	checks := []mergeCheck{m.checkConflict()}
+	checks = append(checks, isLabelMatched(m.getLabels(), m.cfg)...)
+	checks = append(checks, m.checkDCO(log))
+	checks = append(checks, m.checkFreeze(log))
+	checks = append(checks, m.checkReviewPeriod(log))
+	checks = append(checks, m.checkPRPolicy())
+	checks = append(checks, m.checkCommits(log))
+	checks = append(checks, m.checkProtectedPaths(log))
+	checks = append(checks, m.checkNativeReview(log))
+
+	for i := range checks {
+		if !checks[i].Passed {
+			return checks, false
+		}
+	}
+
+	return checks, true
+}
+
+func (m *mergeHelper) getLabels() sets.String {
 	labels := sets.NewString()
 	for _, item := range m.pr.Labels {
 		labels.Insert(item.Name)
 	}
 
-	if r := isLabelMatched(labels, m.cfg); len(r) > 0 {
-		return r, false
+	return labels
+}
+
+func (m *mergeHelper) checkConflict() mergeCheck {
+	c := mergeCheck{
+		Name:   m.cfg.message(checkNoConflict, nil),
+		Passed: m.pr.GetMergeable(),
+	}
+
+	if !c.Passed {
+		c.Detail = m.cfg.message(msgPRConflicts, nil)
 	}
 
+	return c
+}
+
+func (m *mergeHelper) checkFreeze(log *logrus.Entry) mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkNotFrozen, nil)}
+
 	freeze, err := m.getFreezeInfo(log)
 	if err != nil {
-		return nil, false
-	}
+		c.Detail = m.cfg.message(msgFreezeUnknown, nil)
 
-	if freeze == nil || !freeze.isFrozen() {
-		return nil, true
+		return c
 	}
 
-	if m.trigger == "" {
-		return nil, false
-	}
+	if freeze == nil || !freeze.isFrozen() || (m.trigger != "" && freeze.isOwner(m.trigger)) {
+		c.Passed = true
 
-	if freeze.isOwner(m.trigger) {
-		return nil, true
+		return c
 	}
 
-	return []string{
-		fmt.Sprintf(msgFrozenWithOwner, strings.Join(freeze.Owner, ", ")),
-	}, false
+	c.Detail = m.cfg.message(msgFrozenWithOwner, msgData{"Owners": strings.Join(freeze.Owner, ", ")})
+
+	return c
 }
 
 func (m *mergeHelper) getFreezeInfo(log *logrus.Entry) (*freezeItem, error) {
@@ -192,35 +269,64 @@ func (m *mergeHelper) getFreezeContent(f freezeFile) (freezeContent, error) {
 	return fc, err
 }
 
-func isLabelMatched(labels sets.String, cfg *botConfig) []string {
-	var reasons []string
+func isLabelMatched(labels sets.String, cfg *botConfig) []mergeCheck {
+	lgtms := getLGTMLabelsOnPR(labels)
+	sort.Strings(lgtms)
 
-	needs := sets.NewString(approvedLabel)
-	needs.Insert(cfg.LabelsForMerge...)
+	n := countLGTM(labels, cfg)
+	ln := cfg.lgtmCountsRequiredFor(labels)
 
-	if ln := cfg.LgtmCountsRequired; ln == 1 {
-		needs.Insert(lgtmLabel)
-	} else {
-		v := getLGTMLabelsOnPR(labels)
-		if n := uint(len(v)); n < ln {
-			reasons = append(reasons, fmt.Sprintf(msgNotEnoughLGTMLabel, ln, n))
+	lgtm := mergeCheck{
+		Name:   cfg.message(checkLGTM, nil),
+		Passed: n >= ln,
+		Detail: strings.Join(lgtms, ", "),
+	}
+	if !lgtm.Passed {
+		// the holders are kept, so that it is known who has given the lgtm.
+		msg := cfg.message(msgNotEnoughLGTMLabel, msgData{"Required": ln, "Current": n})
+		if len(lgtms) > 0 {
+			msg = fmt.Sprintf("%s (%s)", msg, lgtm.Detail)
 		}
+
+		lgtm.Detail = msg
+	}
+
+	approved := mergeCheck{
+		Name:   cfg.message(checkApproved, nil),
+		Passed: labels.Has(approvedLabel),
+	}
+	if !approved.Passed {
+		approved.Detail = cfg.message(msgMissingLabels, msgData{"Labels": approvedLabel})
+	} else if labels.Has(selfApprovedLabel) {
+		// the self approval is counted distinctly.
+		approved.Passed = cfg.SelfApprovalPolicy != selfApprovalForbid
+		approved.Detail = cfg.message(msgSelfApproved, nil)
 	}
 
+	needs := sets.NewString(cfg.LabelsForMerge...)
+	required := mergeCheck{
+		Name:   cfg.message(checkRequiredLabels, nil),
+		Passed: true,
+		Detail: strings.Join(needs.List(), ", "),
+	}
 	if v := needs.Difference(labels); v.Len() > 0 {
-		reasons = append(reasons, fmt.Sprintf(
-			msgMissingLabels, strings.Join(v.UnsortedList(), ", "),
-		))
+		required.Passed = false
+		required.Detail = cfg.message(
+			msgMissingLabels, msgData{"Labels": strings.Join(v.List(), ", ")},
+		)
 	}
 
-	if len(cfg.MissingLabelsForMerge) > 0 {
-		missing := sets.NewString(cfg.MissingLabelsForMerge...)
-		if v := missing.Intersection(labels); v.Len() > 0 {
-			reasons = append(reasons, fmt.Sprintf(
-				msgInvalidLabels, strings.Join(v.UnsortedList(), ", "),
-			))
-		}
+	forbidden := mergeCheck{
+		Name:   cfg.message(checkForbiddenLabels, nil),
+		Passed: true,
+	}
+	missing := sets.NewString(cfg.MissingLabelsForMerge...)
+	if v := missing.Intersection(labels); v.Len() > 0 {
+		forbidden.Passed = false
+		forbidden.Detail = cfg.message(
+			msgInvalidLabels, msgData{"Labels": strings.Join(v.List(), ", ")},
+		)
 	}
 
-	return reasons
+	return []mergeCheck{lgtm, approved, required, forbidden}
 }
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestTableRows(t *testing.T) {
+	cfg := &botConfig{}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []mergeCheck{
+		{
+			Name:   cfg.message(checkPRPolicy, nil),
+			Detail: cfg.message(msgInvalidTitle, msgData{"Pattern": `^(feat|fix|docs): .+`}),
+		},
+		{
+			Name: cfg.message(checkCommitRules, nil),
+			Detail: cfg.message(msgInvalidCommitMessage, msgData{
+				"Pattern": `^(feat|fix)(\(\w+\))?: `,
+				"Commits": "`abc1234`",
+			}),
+		},
+		{Name: "multiple\nlines", Passed: true, Detail: "a\r\nb"},
+	}
+
+	content := cfg.message(commentPRStatus, msgData{"Checks": tableRows(checks), "Ready": false})
+
+	// the unescaped pipes split the cells.
+	regPipe := regexp.MustCompile(`(^|[^\\])\|`)
+
+	rows := 0
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, "| ") || strings.HasPrefix(line, "| ---") {
+			continue
+		}
+
+		rows++
+
+		if n := len(regPipe.FindAllString(line, -1)); n != 4 {
+			t.Errorf("row has %d cells: %s", n-1, line)
+		}
+	}
+
+	// the header and the checks
+	if rows != len(checks)+1 {
+		t.Errorf("there are %d rows, want %d:\n%s", rows, len(checks)+1, content)
+	}
+
+	if checks[0].Detail != cfg.message(msgInvalidTitle, msgData{"Pattern": `^(feat|fix|docs): .+`}) {
+		t.Error("the checks are modified")
+	}
+}
+
+func TestIsLabelMatchedKeepsLGTMHolders(t *testing.T) {
+	cfg := &botConfig{LgtmCountsRequired: 3}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name   string
+		labels []string
+		passed bool
+	}{
+		{name: "not enough", labels: []string{"lgtm-alice", "lgtm-bob"}},
+		{name: "enough", labels: []string{"lgtm-alice", "lgtm-bob", "lgtm-carol"}, passed: true},
+	}
+
+	for _, tc := range cases {
+		c := isLabelMatched(sets.NewString(tc.labels...), cfg)[0]
+
+		if c.Passed != tc.passed {
+			t.Errorf("%s: passed = %t, want %t", tc.name, c.Passed, tc.passed)
+		}
+
+		for _, l := range tc.labels {
+			if !strings.Contains(c.Detail, l) {
+				t.Errorf("%s: %s is missing in the detail: %s", tc.name, l, c.Detail)
+			}
+		}
+	}
+}
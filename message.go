@@ -0,0 +1,799 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/template"
+)
+
+const (
+	languageEnglish = "en"
+	languageChinese = "zh"
+)
+
+// the ids of messages which can be overridden in the configuration.
+const (
+	msgNotSetReviewer               = "not_set_reviewer"
+	commentAddLGTMBySelf            = "add_lgtm_by_self"
+	commentClearLabel               = "clear_label"
+	commentNoPermissionForLgtmLabel = "no_permission_for_lgtm_label"
+	commentNoPermissionForLabel     = "no_permission_for_label"
+	commentAddLabel                 = "add_label"
+	commentRemovedLabel             = "removed_label"
+	commentNotMergeable             = "not_mergeable"
+	msgPRConflicts                  = "pr_conflicts"
+	msgMissingLabels                = "missing_labels"
+	msgInvalidLabels                = "invalid_labels"
+	msgNotEnoughLGTMLabel           = "not_enough_lgtm_label"
+	msgFrozenWithOwner              = "frozen_with_owner"
+	commentMarkStale                = "mark_stale"
+	commentCloseStale               = "close_stale"
+	commentNeedsOkToTest            = "needs_ok_to_test"
+	commentPRStatus                 = "pr_status"
+	msgFreezeUnknown                = "freeze_unknown"
+	commentRevokedLGTM              = "revoked_lgtm"
+	commentLGTMNotFound             = "lgtm_not_found"
+	commentRevokeSharedLGTM         = "revoke_shared_lgtm"
+	commentAddLGTMByCommitAuthor    = "add_lgtm_by_commit_author"
+	commentSelfApprovalForbidden    = "self_approval_forbidden"
+	msgSelfApproved                 = "self_approved"
+	msgReviewPeriodNotElapsed       = "review_period_not_elapsed"
+	msgReviewPeriodUnknown          = "review_period_unknown"
+	msgInvalidTitle                 = "invalid_title"
+	msgMissingSections              = "missing_sections"
+	msgDescriptionTooShort          = "description_too_short"
+	msgNeedsIssue                   = "needs_issue"
+	commentIssueClosedByPR          = "issue_closed_by_pr"
+	commentDCOFailed                = "dco_failed"
+	msgCommitsUnknown               = "commits_unknown"
+	msgUnsignedCommits              = "unsigned_commits"
+	msgTooManyCommits               = "too_many_commits"
+	msgFixupCommits                 = "fixup_commits"
+	msgInvalidCommitMessage         = "invalid_commit_message"
+	msgCommitsSquashed              = "commits_squashed"
+	commentNeedsRebase              = "needs_rebase"
+
+	commentNoPermissionForCherryPick = "no_permission_for_cherry_pick"
+	commentCherryPickScheduled       = "cherry_pick_scheduled"
+	commentCherryPickCreated         = "cherry_pick_created"
+	commentCherryPickConflict        = "cherry_pick_conflict"
+	commentCherryPickFailed          = "cherry_pick_failed"
+	commentCherryPickInvalidBranch   = "cherry_pick_invalid_branch"
+	msgCherryPickBody                = "cherry_pick_body"
+	msgProtectedPathNeedsApproval    = "protected_path_needs_approval"
+	msgProtectedPathsUnknown         = "protected_paths_unknown"
+
+	commentHelp                       = "help"
+	msgPermissionAnyone               = "permission_anyone"
+	msgPermissionAuthorOrCollaborator = "permission_author_or_collaborator"
+	msgPermissionCollaborator         = "permission_collaborator"
+	commentNoPermissionForCommand     = "no_permission_for_command"
+	msgHelpLGTM                       = "help_lgtm"
+	msgHelpRequiredLabels             = "help_required_labels"
+	msgHelpForbiddenLabels            = "help_forbidden_labels"
+	msgHelpSelfApprovalForbidden      = "help_self_approval_forbidden"
+	msgHelpSelfApprovalExtraLGTM      = "help_self_approval_extra_lgtm"
+	msgHelpSizeExtraLGTM              = "help_size_extra_lgtm"
+	msgHelpDCO                        = "help_dco"
+	msgHelpNotFrozen                  = "help_not_frozen"
+	msgHelpReviewPeriod               = "help_review_period"
+	msgHelpPRPolicy                   = "help_pr_policy"
+	msgHelpCommitRules                = "help_commit_rules"
+	msgHelpProtectedPath              = "help_protected_path"
+	msgHelpTestPass                   = "help_test_pass"
+	msgTestNotPassed                  = "test_not_passed"
+	msgReviewNotPassed                = "review_not_passed"
+	msgNativeReviewUnknown            = "native_review_unknown"
+
+	checkNoConflict      = "check_no_conflict"
+	checkLGTM            = "check_lgtm"
+	checkApproved        = "check_approved"
+	checkRequiredLabels  = "check_required_labels"
+	checkForbiddenLabels = "check_forbidden_labels"
+	checkNotFrozen       = "check_not_frozen"
+	checkReviewPeriod    = "check_review_period"
+	checkPRPolicy        = "check_pr_policy"
+	checkDCO             = "check_dco"
+	checkCommitRules     = "check_commit_rules"
+	checkProtectedPaths  = "check_protected_paths"
+	checkNativeReview    = "check_native_review"
+)
+
+// the markdown table of the merge conditions which is a list of mergeCheck.
+const (
+	checklistTemplate = `| Condition | Status | Detail |
+| --- | --- | --- |
+{{range .Checks}}| {{.Name}} | {{if .Passed}}:white_check_mark:{{else}}:x:{{end}} | {{.Detail}} |
+{{end}}`
+	checklistTemplateZh = `| 条件 | 状态 | 详情 |
+| --- | --- | --- |
+{{range .Checks}}| {{.Name}} | {{if .Passed}}:white_check_mark:{{else}}:x:{{end}} | {{.Detail}} |
+{{end}}`
+)
+
+// msgData is the data used to render the template of message.
+type msgData map[string]interface{}
+
+// messageSpec is the built-in template of a message in each language,
+// params are the fields of msgData which can be used by the template,
+// and their values are the samples of the real types used to validate the template.
+type messageSpec struct {
+	params msgData
+	text   map[string]string
+}
+
+var messageSpecs = map[string]messageSpec{
+	msgNotSetReviewer: {
+		params: msgData{"Author": "Author"},
+		text: map[string]string{
+			languageEnglish: "**@{{.Author}}** Thank you for submitting a PullRequest. It is detected that you have not set a reviewer, please set a one.",
+			languageChinese: "**@{{.Author}}** 感谢您提交PullRequest。检测到您还没有设置审查者，请设置一个。",
+		},
+	},
+	commentAddLGTMBySelf: {
+		text: map[string]string{
+			languageEnglish: "***lgtm*** can not be added in your self-own pull request. :astonished:",
+			languageChinese: "不能在自己提交的Pull Request中添加***lgtm***。 :astonished:",
+		},
+	},
+	commentAddLGTMByCommitAuthor: {
+		text: map[string]string{
+			languageEnglish: "***lgtm*** can not be added by the authors of commits in this pull request. :astonished:",
+			languageChinese: "这个Pull Request中提交的作者不能添加***lgtm***。 :astonished:",
+		},
+	},
+	commentSelfApprovalForbidden: {
+		params: msgData{"Commenter": "Commenter"},
+		text: map[string]string{
+			languageEnglish: "***@{{.Commenter}}***, ***approved*** can not be added in your self-own pull request. :astonished:",
+			languageChinese: "***@{{.Commenter}}***，不能在自己提交的Pull Request中添加***approved***。 :astonished:",
+		},
+	},
+	msgSelfApproved: {
+		text: map[string]string{
+			languageEnglish: "approved by the author of PR",
+			languageChinese: "由PR作者approve",
+		},
+	},
+	commentClearLabel: {
+		params: msgData{"Labels": "Labels"},
+		text: map[string]string{
+			languageEnglish: "New code changes of pr are detected and remove these labels ***{{.Labels}}***. :flushed: ",
+			languageChinese: "检测到PR有新的代码变更，移除以下标签：***{{.Labels}}***。 :flushed: ",
+		},
+	},
+	commentNoPermissionForLgtmLabel: {
+		params: msgData{"Commenter": "Commenter"},
+		text: map[string]string{
+			languageEnglish: `Thanks for your review, ***{{.Commenter}}***, your opinion is very important to us.:wave:
+The maintainers will consider your advice carefully.`,
+			languageChinese: `感谢您的评审，***{{.Commenter}}***，您的意见对我们非常重要。:wave:
+维护者们会认真考虑您的建议。`,
+		},
+	},
+	commentNoPermissionForLabel: {
+		params: msgData{"Commenter": "Commenter", "Action": "Action", "Label": "Label"},
+		text: map[string]string{
+			languageEnglish: `
+***@{{.Commenter}}*** has no permission to {{.Action}} ***{{.Label}}*** label in this pull request. :astonished:
+Please contact to the collaborators in this repository.`,
+			languageChinese: `
+***@{{.Commenter}}*** 没有权限在这个Pull Request中{{if eq .Action "add"}}添加{{else}}删除{{end}} ***{{.Label}}*** 标签。 :astonished:
+请联系这个仓库的协作者。`,
+		},
+	},
+	commentAddLabel: {
+		params: msgData{"Label": "Label", "Commenter": "Commenter"},
+		text: map[string]string{
+			languageEnglish: `***{{.Label}}*** was added to this pull request by: ***{{.Commenter}}***. :wave:
+**NOTE:** If this pull request is not merged while all conditions are met, comment "/check-pr" to try again. :smile: `,
+			languageChinese: `***{{.Commenter}}*** 为这个Pull Request添加了 ***{{.Label}}*** 标签。 :wave:
+**注意：** 如果满足所有合入条件后这个Pull Request仍未合入，请评论"/check-pr"重试。 :smile: `,
+		},
+	},
+	commentRemovedLabel: {
+		params: msgData{"Label": "Label", "Commenter": "Commenter"},
+		text: map[string]string{
+			languageEnglish: "***{{.Label}}*** was removed in this pull request by: ***{{.Commenter}}***. :flushed: ",
+			languageChinese: "***{{.Commenter}}*** 删除了这个Pull Request的 ***{{.Label}}*** 标签。 :flushed: ",
+		},
+	},
+	commentNotMergeable: {
+		params: msgData{"Commenter": "Commenter", "Checks": []mergeCheck{{Name: "Name", Detail: "Detail"}}},
+		text: map[string]string{
+			languageEnglish: "@{{.Commenter}} , this pr is not mergeable and the conditions are below:\n" + checklistTemplate,
+			languageChinese: "@{{.Commenter}} ，这个PR不能合入，合入条件如下：\n" + checklistTemplateZh,
+		},
+	},
+	msgPRConflicts: {
+		text: map[string]string{
+			languageEnglish: "PR conflicts to the target branch.",
+			languageChinese: "PR与目标分支存在冲突。",
+		},
+	},
+	msgMissingLabels: {
+		params: msgData{"Labels": "Labels"},
+		text: map[string]string{
+			languageEnglish: "PR does not have these lables: {{.Labels}}",
+			languageChinese: "PR缺少这些标签：{{.Labels}}",
+		},
+	},
+	msgInvalidLabels: {
+		params: msgData{"Labels": "Labels"},
+		text: map[string]string{
+			languageEnglish: "PR should remove these labels: {{.Labels}}",
+			languageChinese: "PR需要移除这些标签：{{.Labels}}",
+		},
+	},
+	msgNotEnoughLGTMLabel: {
+		params: msgData{"Required": uint(2), "Current": uint(1)},
+		text: map[string]string{
+			languageEnglish: "PR needs {{.Required}} lgtm labels and now gets {{.Current}}",
+			languageChinese: "PR需要{{.Required}}个lgtm标签，当前有{{.Current}}个",
+		},
+	},
+	msgFrozenWithOwner: {
+		params: msgData{"Owners": "Owners"},
+		text: map[string]string{
+			languageEnglish: "The target branch of PR has been frozen and it can be merge only by branch owners: {{.Owners}}",
+			languageChinese: "PR的目标分支已冻结，只有分支负责人才能合入：{{.Owners}}",
+		},
+	},
+	commentMarkStale: {
+		params: msgData{"Label": "Label", "DaysUntilStale": uint(1), "DaysUntilClose": uint(1)},
+		text: map[string]string{
+			languageEnglish: `This pull request has had no activity for {{.DaysUntilStale}} days and is marked as ***{{.Label}}***. :hourglass:
+Comment "/remove-lifecycle stale" to remove the mark, or "/lifecycle frozen" to keep it open forever.
+{{- if .DaysUntilClose}}
+It will be closed in {{.DaysUntilClose}} days if no further activity occurs.{{end}}`,
+			languageChinese: `这个Pull Request已经{{.DaysUntilStale}}天没有活动，被标记为 ***{{.Label}}***。 :hourglass:
+评论"/remove-lifecycle stale"可以移除该标记，评论"/lifecycle frozen"可以使其一直保持打开。
+{{- if .DaysUntilClose}}
+如果{{.DaysUntilClose}}天内仍没有活动，它将被关闭。{{end}}`,
+		},
+	},
+	commentCloseStale: {
+		params: msgData{"Label": "Label", "DaysUntilClose": uint(1)},
+		text: map[string]string{
+			languageEnglish: "This pull request is closed because it has been ***{{.Label}}*** for {{.DaysUntilClose}} days with no activity. :wave:",
+			languageChinese: "这个Pull Request已经 ***{{.Label}}*** {{.DaysUntilClose}}天没有活动，因此被关闭。 :wave:",
+		},
+	},
+	commentNeedsOkToTest: {
+		params: msgData{"Author": "Author"},
+		text: map[string]string{
+			languageEnglish: `Thanks for your pull request, ***@{{.Author}}***. :wave:
+It seems that you are a new contributor of this repository, so the CI will not be triggered automatically.
+The collaborators will comment "/ok-to-test" to run the CI after checking the changes.`,
+			languageChinese: `感谢您提交的Pull Request，***@{{.Author}}***。 :wave:
+您似乎是这个仓库的新贡献者，因此不会自动触发CI。
+协作者检查变更后会评论"/ok-to-test"来运行CI。`,
+		},
+	},
+	commentPRStatus: {
+		params: msgData{"Checks": []mergeCheck{{Name: "Name", Detail: "Detail"}}, "Ready": true},
+		text: map[string]string{
+			languageEnglish: "### Review status\n" + checklistTemplate + `
+{{if .Ready}}This pull request is ready to be merged. :tada:{{else}}This pull request can not be merged yet. Comment "/check-pr" to refresh. :hourglass:{{end}}`,
+			languageChinese: "### 评审状态\n" + checklistTemplateZh + `
+{{if .Ready}}这个Pull Request已满足合入条件。 :tada:{{else}}这个Pull Request暂不能合入，评论"/check-pr"可刷新状态。 :hourglass:{{end}}`,
+		},
+	},
+	commentRevokedLGTM: {
+		params: msgData{"Label": "Label", "Commenter": "Commenter", "Reviewer": "Reviewer"},
+		text: map[string]string{
+			languageEnglish: "***{{.Label}}*** of ***@{{.Reviewer}}*** was revoked in this pull request by: ***@{{.Commenter}}***. :flushed: ",
+			languageChinese: "***@{{.Commenter}}*** 撤销了 ***@{{.Reviewer}}*** 在这个Pull Request中的 ***{{.Label}}*** 标签。 :flushed: ",
+		},
+	},
+	commentLGTMNotFound: {
+		params: msgData{"Commenter": "Commenter", "Reviewer": "Reviewer"},
+		text: map[string]string{
+			languageEnglish: "***@{{.Commenter}}***, there is no lgtm of ***@{{.Reviewer}}*** in this pull request. :confused:",
+			languageChinese: "***@{{.Commenter}}***，这个Pull Request中没有 ***@{{.Reviewer}}*** 的lgtm。 :confused:",
+		},
+	},
+	commentRevokeSharedLGTM: {
+		params: msgData{"Commenter": "Commenter"},
+		text: map[string]string{
+			languageEnglish: "***@{{.Commenter}}***, the lgtm of a specific reviewer can't be revoked because only one lgtm is required in this repository. Comment /lgtm cancel to remove the ***lgtm*** label instead.",
+			languageChinese: "***@{{.Commenter}}***，这个仓库只需要一个lgtm，无法撤销指定评审者的lgtm。请评论/lgtm cancel删除***lgtm***标签。",
+		},
+	},
+
+	checkNoConflict: {
+		text: map[string]string{
+			languageEnglish: "no conflict",
+			languageChinese: "无冲突",
+		},
+	},
+	checkLGTM: {
+		text: map[string]string{
+			languageEnglish: "lgtm",
+			languageChinese: "lgtm",
+		},
+	},
+	checkApproved: {
+		text: map[string]string{
+			languageEnglish: "approved",
+			languageChinese: "approved",
+		},
+	},
+	checkRequiredLabels: {
+		text: map[string]string{
+			languageEnglish: "required labels",
+			languageChinese: "必需标签",
+		},
+	},
+	checkForbiddenLabels: {
+		text: map[string]string{
+			languageEnglish: "forbidden labels",
+			languageChinese: "禁止标签",
+		},
+	},
+	checkNotFrozen: {
+		text: map[string]string{
+			languageEnglish: "branch not frozen",
+			languageChinese: "分支未冻结",
+		},
+	},
+	checkReviewPeriod: {
+		text: map[string]string{
+			languageEnglish: "review period",
+			languageChinese: "评审期",
+		},
+	},
+	msgReviewPeriodNotElapsed: {
+		params: msgData{"Duration": "Duration", "Time": "Time"},
+		text: map[string]string{
+			languageEnglish: "The pull request must stay open for {{.Duration}} to be reviewed. It will be merged automatically after {{.Time}} if the other conditions are met.",
+			languageChinese: "Pull Request需保持打开{{.Duration}}以供评审，满足其它条件时将在{{.Time}}之后自动合入。",
+		},
+	},
+	msgReviewPeriodUnknown: {
+		text: map[string]string{
+			languageEnglish: "Failed to get the start time of the review period.",
+			languageChinese: "获取评审期的开始时间失败。",
+		},
+	},
+	checkPRPolicy: {
+		text: map[string]string{
+			languageEnglish: "title and description",
+			languageChinese: "标题和描述",
+		},
+	},
+	msgInvalidTitle: {
+		params: msgData{"Pattern": "Pattern"},
+		text: map[string]string{
+			languageEnglish: "The title does not match `{{.Pattern}}`.",
+			languageChinese: "标题不符合`{{.Pattern}}`。",
+		},
+	},
+	msgMissingSections: {
+		params: msgData{"Sections": "Sections"},
+		text: map[string]string{
+			languageEnglish: "The description misses the sections: {{.Sections}}.",
+			languageChinese: "描述缺少以下部分：{{.Sections}}。",
+		},
+	},
+	msgDescriptionTooShort: {
+		params: msgData{"Required": uint(2), "Current": uint(1)},
+		text: map[string]string{
+			languageEnglish: "The description has {{.Current}} characters, but at least {{.Required}} are required.",
+			languageChinese: "描述只有{{.Current}}个字符，至少需要{{.Required}}个。",
+		},
+	},
+	msgNeedsIssue: {
+		text: map[string]string{
+			languageEnglish: "The description does not refer to any issue.",
+			languageChinese: "描述中没有关联任何issue。",
+		},
+	},
+	commentIssueClosedByPR: {
+		params: msgData{"URL": "URL"},
+		text: map[string]string{
+			languageEnglish: "This issue is closed by the merged pull request: {{.URL}}",
+			languageChinese: "这个issue已由合入的Pull Request关闭：{{.URL}}",
+		},
+	},
+	checkDCO: {
+		text: map[string]string{
+			languageEnglish: "DCO",
+			languageChinese: "DCO",
+		},
+	},
+	commentDCOFailed: {
+		params: msgData{"Author": "Author", "Commits": "Commits"},
+		text: map[string]string{
+			languageEnglish: `@{{.Author}} , the commits below are not signed off by their authors:
+{{.Commits}}
+
+Please add the ***Signed-off-by*** line with the same email as the author of commit, such as running ` + "`git commit --amend -s`" + ` or ` + "`git rebase --signoff`" + `, and push the commits again.`,
+			languageChinese: `@{{.Author}} ，以下commit没有作者的签名：
+{{.Commits}}
+
+请添加与commit作者邮箱一致的***Signed-off-by***行，例如执行` + "`git commit --amend -s`" + `或` + "`git rebase --signoff`" + `，然后重新推送commit。`,
+		},
+	},
+	checkCommitRules: {
+		text: map[string]string{
+			languageEnglish: "commits",
+			languageChinese: "commit规范",
+		},
+	},
+	msgCommitsUnknown: {
+		text: map[string]string{
+			languageEnglish: "Failed to get the commits of the pull request.",
+			languageChinese: "获取Pull Request的commit失败。",
+		},
+	},
+	msgUnsignedCommits: {
+		params: msgData{"Commits": "Commits"},
+		text: map[string]string{
+			languageEnglish: "The commits are not signed off by their authors: {{.Commits}}.",
+			languageChinese: "以下commit没有作者的签名：{{.Commits}}。",
+		},
+	},
+	msgTooManyCommits: {
+		params: msgData{"Max": uint(2), "Current": uint(1)},
+		text: map[string]string{
+			languageEnglish: "There are {{.Current}} commits, but at most {{.Max}} are allowed.",
+			languageChinese: "共有{{.Current}}个commit，最多允许{{.Max}}个。",
+		},
+	},
+	msgFixupCommits: {
+		params: msgData{"Commits": "Commits"},
+		text: map[string]string{
+			languageEnglish: "The fixup or squash commits are not allowed: {{.Commits}}.",
+			languageChinese: "不允许fixup或squash类型的commit：{{.Commits}}。",
+		},
+	},
+	msgInvalidCommitMessage: {
+		params: msgData{"Pattern": "Pattern", "Commits": "Commits"},
+		text: map[string]string{
+			languageEnglish: "The titles of commits do not match `{{.Pattern}}`: {{.Commits}}.",
+			languageChinese: "以下commit的标题不符合`{{.Pattern}}`：{{.Commits}}。",
+		},
+	},
+	msgCommitsSquashed: {
+		text: map[string]string{
+			languageEnglish: "The commits will be squashed when merged.",
+			languageChinese: "合入时将压缩为一个commit。",
+		},
+	},
+	commentNeedsRebase: {
+		params: msgData{"Author": "Author", "Label": "Label"},
+		text: map[string]string{
+			languageEnglish: "@{{.Author}} , this pull request conflicts with the target branch and is labeled with ***{{.Label}}***. Please rebase it, and the label will be removed once it is mergeable again. :pray:",
+			languageChinese: "@{{.Author}} ，这个Pull Request与目标分支存在冲突，已添加***{{.Label}}***标签。请进行rebase，可合入后该标签会被自动删除。 :pray:",
+		},
+	},
+	commentNoPermissionForCherryPick: {
+		params: msgData{"Commenter": "Commenter"},
+		text: map[string]string{
+			languageEnglish: "***@{{.Commenter}}*** has no permission to cherry-pick this pull request. :astonished:",
+			languageChinese: "***@{{.Commenter}}*** 没有权限cherry-pick这个Pull Request。 :astonished:",
+		},
+	},
+	commentCherryPickScheduled: {
+		params: msgData{"Commenter": "Commenter", "Branches": "Branches"},
+		text: map[string]string{
+			languageEnglish: "***@{{.Commenter}}***, this pull request will be cherry-picked to {{.Branches}} after it is merged. :ok_hand:",
+			languageChinese: "***@{{.Commenter}}***，这个Pull Request合入后将被cherry-pick到{{.Branches}}。 :ok_hand:",
+		},
+	},
+	commentCherryPickCreated: {
+		params: msgData{"Target": "Target", "URL": "URL"},
+		text: map[string]string{
+			languageEnglish: "This pull request is cherry-picked to ***{{.Target}}***: {{.URL}}",
+			languageChinese: "这个Pull Request已cherry-pick到***{{.Target}}***：{{.URL}}",
+		},
+	},
+	commentCherryPickConflict: {
+		params: msgData{"Target": "Target", "Files": "Files"},
+		text: map[string]string{
+			languageEnglish: "Failed to cherry-pick this pull request to ***{{.Target}}*** because of the conflicts in: {{.Files}}. Please backport it manually. :sweat:",
+			languageChinese: "这个Pull Request cherry-pick到***{{.Target}}***时以下文件存在冲突：{{.Files}}，请手动回合。 :sweat:",
+		},
+	},
+	commentCherryPickFailed: {
+		params: msgData{"Target": "Target"},
+		text: map[string]string{
+			languageEnglish: "Failed to cherry-pick this pull request to ***{{.Target}}***. Please check whether the branch exists or backport it manually. :sweat:",
+			languageChinese: "这个Pull Request cherry-pick到***{{.Target}}***失败，请检查分支是否存在或手动回合。 :sweat:",
+		},
+	},
+	commentCherryPickInvalidBranch: {
+		params: msgData{"Target": "Target"},
+		text: map[string]string{
+			languageEnglish: "Can't cherry-pick this pull request to ***{{.Target}}*** because it is not a valid branch name. :astonished:",
+			languageChinese: "***{{.Target}}***不是合法的分支名，无法cherry-pick这个Pull Request。 :astonished:",
+		},
+	},
+	msgCherryPickBody: {
+		params: msgData{"Number": int32(1), "URL": "URL", "Target": "Target"},
+		text: map[string]string{
+			languageEnglish: "This is an automated cherry-pick of !{{.Number}} to {{.Target}}.\n\nThe original pull request: {{.URL}}",
+			languageChinese: "这是!{{.Number}}到{{.Target}}的自动cherry-pick。\n\n原Pull Request：{{.URL}}",
+		},
+	},
+	checkProtectedPaths: {
+		text: map[string]string{
+			languageEnglish: "protected paths",
+			languageChinese: "受保护路径",
+		},
+	},
+	msgProtectedPathNeedsApproval: {
+		params: msgData{"Paths": "Paths", "Approvers": "Approvers"},
+		text: map[string]string{
+			languageEnglish: "The changes of {{.Paths}} need /approve of one of: {{.Approvers}}.",
+			languageChinese: "{{.Paths}}的修改需要以下人员之一/approve：{{.Approvers}}。",
+		},
+	},
+	msgProtectedPathsUnknown: {
+		text: map[string]string{
+			languageEnglish: "Failed to check the approval of protected paths.",
+			languageChinese: "检查受保护路径的审批失败。",
+		},
+	},
+	commentHelp: {
+		params: msgData{
+			"Commands":    []helpCommand{{Name: "/help", Permission: "anyone"}},
+			"Conditions":  []string{"Conditions"},
+			"MergeMethod": "MergeMethod",
+		},
+		text: map[string]string{
+			languageEnglish: `The commands available in this repository:
+
+| Command | Who can use |
+| --- | --- |
+{{range .Commands}}| {{.Name}} | {{.Permission}} |
+{{end}}
+The pull request can be merged when:
+{{range .Conditions}}- {{.}}
+{{end}}
+It will be merged by the ***{{.MergeMethod}}*** method.`,
+			languageChinese: `这个仓库可用的命令：
+
+| 命令 | 谁能使用 |
+| --- | --- |
+{{range .Commands}}| {{.Name}} | {{.Permission}} |
+{{end}}
+Pull Request满足以下条件时可以合入：
+{{range .Conditions}}- {{.}}
+{{end}}
+合入方式为***{{.MergeMethod}}***。`,
+		},
+	},
+	msgHelpLGTM: {
+		params: msgData{"Count": uint(1)},
+		text: map[string]string{
+			languageEnglish: "it has {{.Count}} ***lgtm*** at least",
+			languageChinese: "至少有{{.Count}}个***lgtm***",
+		},
+	},
+	msgHelpRequiredLabels: {
+		params: msgData{"Labels": "Labels"},
+		text: map[string]string{
+			languageEnglish: "it has the labels: {{.Labels}}",
+			languageChinese: "有以下标签：{{.Labels}}",
+		},
+	},
+	msgHelpForbiddenLabels: {
+		params: msgData{"Labels": "Labels"},
+		text: map[string]string{
+			languageEnglish: "it does not have the labels: {{.Labels}}",
+			languageChinese: "没有以下标签：{{.Labels}}",
+		},
+	},
+	msgHelpSelfApprovalForbidden: {
+		text: map[string]string{
+			languageEnglish: "it is not approved by its author only",
+			languageChinese: "不能仅由PR作者approve",
+		},
+	},
+	msgHelpSelfApprovalExtraLGTM: {
+		params: msgData{"Count": uint(1)},
+		text: map[string]string{
+			languageEnglish: "it needs {{.Count}} more ***lgtm*** if it is approved by its author only",
+			languageChinese: "仅由PR作者approve时需额外{{.Count}}个***lgtm***",
+		},
+	},
+	msgHelpSizeExtraLGTM: {
+		params: msgData{"Count": uint(1)},
+		text: map[string]string{
+			languageEnglish: "it needs {{.Count}} more ***lgtm*** if it is labeled with size/XL or size/XXL",
+			languageChinese: "有size/XL或size/XXL标签时需额外{{.Count}}个***lgtm***",
+		},
+	},
+	msgHelpDCO: {
+		text: map[string]string{
+			languageEnglish: "all its commits are signed off by their authors",
+			languageChinese: "所有commit都有作者的签名",
+		},
+	},
+	msgHelpNotFrozen: {
+		text: map[string]string{
+			languageEnglish: "the target branch is not frozen",
+			languageChinese: "目标分支没有被冻结",
+		},
+	},
+	msgHelpReviewPeriod: {
+		params: msgData{"Duration": "Duration", "SinceLastPush": true},
+		text: map[string]string{
+			languageEnglish: "it has been open for {{.Duration}}{{if .SinceLastPush}} since the last push{{end}}",
+			languageChinese: "{{if .SinceLastPush}}最后一次push后{{end}}已打开{{.Duration}}",
+		},
+	},
+	msgHelpPRPolicy: {
+		text: map[string]string{
+			languageEnglish: "its title and description follow the PR policy of this repository",
+			languageChinese: "标题和描述符合这个仓库的PR规范",
+		},
+	},
+	msgHelpCommitRules: {
+		params: msgData{"Squash": true},
+		text: map[string]string{
+			languageEnglish: "its commits follow the commit rules of this repository{{if .Squash}}, otherwise it is squashed when merged{{end}}",
+			languageChinese: "commit符合这个仓库的commit规范{{if .Squash}}，否则合入时会被squash{{end}}",
+		},
+	},
+	msgHelpProtectedPath: {
+		params: msgData{"Paths": "Paths", "Approvers": "Approvers"},
+		text: map[string]string{
+			languageEnglish: "the changes of {{.Paths}} are approved by one of {{.Approvers}} after the last push",
+			languageChinese: "{{.Paths}}的修改在最后一次push后由{{.Approvers}}之一approve",
+		},
+	},
+	msgHelpTestPass: {
+		text: map[string]string{
+			languageEnglish: "it is passed by the testers on gitee",
+			languageChinese: "码云上测试通过",
+		},
+	},
+	msgPermissionAnyone: {
+		text: map[string]string{
+			languageEnglish: "Anyone",
+			languageChinese: "任何人",
+		},
+	},
+	msgPermissionAuthorOrCollaborator: {
+		text: map[string]string{
+			languageEnglish: "Collaborators of this repository and the author of pull request",
+			languageChinese: "这个仓库的协作者以及Pull Request的作者",
+		},
+	},
+	msgPermissionCollaborator: {
+		text: map[string]string{
+			languageEnglish: "Collaborators of this repository",
+			languageChinese: "这个仓库的协作者",
+		},
+	},
+	commentNoPermissionForCommand: {
+		params: msgData{"Commenter": "Commenter", "Command": "Command"},
+		text: map[string]string{
+			languageEnglish: "***@{{.Commenter}}*** has no permission to use the command ***/{{.Command}}*** in this pull request. :astonished:",
+			languageChinese: "***@{{.Commenter}}*** 没有权限在这个Pull Request中使用 ***/{{.Command}}*** 命令。 :astonished:",
+		},
+	},
+	checkNativeReview: {
+		text: map[string]string{
+			languageEnglish: "gitee review",
+			languageChinese: "码云评审",
+		},
+	},
+	msgTestNotPassed: {
+		params: msgData{"Required": 1, "Current": 0},
+		text: map[string]string{
+			languageEnglish: "{{.Current}} of {{.Required}} testers have passed the test on gitee.",
+			languageChinese: "码云上{{.Required}}个测试者中已有{{.Current}}个测试通过。",
+		},
+	},
+	msgReviewNotPassed: {
+		params: msgData{"Required": 1, "Current": 0},
+		text: map[string]string{
+			languageEnglish: "{{.Current}} of {{.Required}} reviewers have passed the review on gitee.",
+			languageChinese: "码云上{{.Required}}个审查者中已有{{.Current}}个审查通过。",
+		},
+	},
+	msgNativeReviewUnknown: {
+		text: map[string]string{
+			languageEnglish: "Failed to get the review and test state of the pull request on gitee.",
+			languageChinese: "获取Pull Request在码云上的审查和测试状态失败。",
+		},
+	},
+	msgFreezeUnknown: {
+		text: map[string]string{
+			languageEnglish: "Failed to get the freeze information of the target branch.",
+			languageChinese: "获取目标分支的冻结信息失败。",
+		},
+	},
+}
+
+// messageTemplates holds the parsed templates of all the messages for a repo.
+type messageTemplates map[string]*template.Template
+
+func newMessageTemplates(language string, overrides map[string]string) (messageTemplates, error) {
+	if language == "" {
+		language = languageEnglish
+	}
+
+	if language != languageEnglish && language != languageChinese {
+		return nil, fmt.Errorf("unsupported language:%s", language)
+	}
+
+	for id := range overrides {
+		if _, ok := messageSpecs[id]; !ok {
+			return nil, fmt.Errorf("unknown message:%s, valid ones are: %v", id, messageIDs())
+		}
+	}
+
+	r := make(messageTemplates, len(messageSpecs))
+
+	for id, spec := range messageSpecs {
+		text, ok := overrides[id]
+		if !ok {
+			text = spec.text[language]
+		}
+
+		t, err := parseMessageTemplate(id, text, spec)
+		if err != nil {
+			return nil, err
+		}
+
+		r[id] = t
+	}
+
+	return r, nil
+}
+
+// parseMessageTemplate parses the template and renders it with sample data,
+// so that the template which refers to unknown field fails here instead of in handler.
+func parseMessageTemplate(id, text string, spec messageSpec) (*template.Template, error) {
+	t, err := template.New(id).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template of message:%s, err:%s", id, err.Error())
+	}
+
+	data := spec.params
+	if data == nil {
+		data = msgData{}
+	}
+
+	if err := t.Execute(new(bytes.Buffer), data); err != nil {
+		return nil, fmt.Errorf("invalid template of message:%s, err:%s", id, err.Error())
+	}
+
+	return t, nil
+}
+
+func (mt messageTemplates) render(id string, data msgData) string {
+	if t, ok := mt[id]; ok {
+		b := new(bytes.Buffer)
+		if err := t.Execute(b, data); err == nil {
+			return b.String()
+		}
+	}
+
+	// fall back to the built-in english message
+	b := new(bytes.Buffer)
+
+	t, err := template.New(id).Parse(messageSpecs[id].text[languageEnglish])
+	if err != nil || t.Execute(b, data) != nil {
+		return id
+	}
+
+	return b.String()
+}
+
+func messageIDs() []string {
+	v := make([]string, 0, len(messageSpecs))
+	for id := range messageSpecs {
+		v = append(v, id)
+	}
+
+	sort.Strings(v)
+
+	return v
+}
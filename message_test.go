@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestNewMessageTemplates(t *testing.T) {
+	cases := []struct {
+		name      string
+		language  string
+		overrides map[string]string
+		valid     bool
+	}{
+		{name: "english", language: languageEnglish, valid: true},
+		{name: "chinese", language: languageChinese, valid: true},
+		{name: "unknown language", language: "fr"},
+		{
+			name:      "compare numbers",
+			overrides: map[string]string{msgNotEnoughLGTMLabel: "{{if gt .Required 1}}{{.Current}}/{{.Required}}{{end}}"},
+			valid:     true,
+		},
+		{
+			name:      "range checks",
+			overrides: map[string]string{commentPRStatus: "{{if .Ready}}ok{{end}}{{range .Checks}}{{.Name}}{{end}}"},
+			valid:     true,
+		},
+		{
+			name:      "unknown param",
+			overrides: map[string]string{commentAddLabel: "{{.Reviewer}}"},
+		},
+		{
+			name:      "unknown field",
+			overrides: map[string]string{commentPRStatus: "{{range .Checks}}{{.Owner}}{{end}}"},
+		},
+		{
+			name:      "unknown message",
+			overrides: map[string]string{"unknown": "text"},
+		},
+		{
+			name:      "invalid syntax",
+			overrides: map[string]string{commentAddLabel: "{{.Label"},
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := newMessageTemplates(tc.language, tc.overrides)
+		if (err == nil) != tc.valid {
+			t.Errorf("%s: err = %v, want valid: %v", tc.name, err, tc.valid)
+		}
+	}
+}
+
+func TestMessageTemplatesRender(t *testing.T) {
+	mt, err := newMessageTemplates(languageEnglish, map[string]string{
+		msgNotEnoughLGTMLabel: "{{.Current}} of {{.Required}}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := mt.render(msgNotEnoughLGTMLabel, msgData{"Required": uint(2), "Current": uint(1)}); v != "1 of 2" {
+		t.Errorf("render = %q", v)
+	}
+
+	// it falls back to the built-in message if the data doesn't match the override.
+	if v := mt.render(msgNotEnoughLGTMLabel, msgData{}); v == "" {
+		t.Error("render returns empty")
+	}
+}
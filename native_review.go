@@ -0,0 +1,188 @@
+package main
+
+import (
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// handleNativeReview adds the lgtm labels for the reviewers who pass the review of PR on gitee,
+// as if they comment /lgtm. It also records the passes made before the push of PR.
+func (bot *robot) handleNativeReview(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	if !cfg.NativeReview.enabled() {
+		return nil
+	}
+
+	action := giteeclient.GetPullRequestAction(e)
+
+	// the labels are mostly updated by the bot itself.
+	if action == giteeclient.PRActionClosed || action == giteeclient.PRActionUpdatedLabel {
+		return nil
+	}
+
+	if hook := e.GetPullRequest(); hook == nil || hook.State != "open" {
+		return nil
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+
+	v, err := bot.cli.GetGiteePullRequest(pr.Org, pr.Repo, pr.Number)
+	if err != nil {
+		return err
+	}
+
+	// the lgtm labels are cleared when the source branch is changed,
+	// so the passes before it must not add them again.
+	key := genPRKey(pr.Org, pr.Repo, pr.Number)
+	if action == giteeclient.PRActionChangedSourceBranch {
+		bot.pushes.setStalePasses(key, &v)
+
+		return nil
+	}
+
+	if !cfg.NativeReview.ReviewAsLgtm {
+		return nil
+	}
+
+	reviewers, _ := bot.pushes.freshPasses(key, &v)
+
+	merr := utils.NewMultiErrors()
+	added := map[string]bool{}
+
+	for _, reviewer := range reviewers {
+		label := genLGTMLabel(reviewer, cfg.LgtmCountsRequired)
+		if pr.Labels.Has(label) || added[label] {
+			continue
+		}
+
+		ok, err := bot.isValidReviewer(reviewer, pr, cfg, log)
+		if err != nil {
+			merr.AddError(err)
+
+			continue
+		}
+
+		if !ok {
+			continue
+		}
+
+		if label != lgtmLabel {
+			if err := bot.createLabelIfNeed(pr.Org, pr.Repo, label); err != nil {
+				log.WithError(err).Errorf("create repo label: %s", label)
+			}
+		}
+
+		if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, label); err != nil {
+			merr.AddError(err)
+
+			continue
+		}
+
+		added[label] = true
+
+		err = bot.notify(
+			pr.Org, pr.Repo, pr.Number, cfg,
+			cfg.message(commentAddLabel, msgData{"Label": label, "Commenter": reviewer}), log,
+		)
+		if err != nil {
+			log.Error(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+// isValidReviewer checks whether the reviewer can add lgtm to the PR in the same way as /lgtm.
+func (bot *robot) isValidReviewer(
+	reviewer string, pr giteeclient.PRInfo, cfg *botConfig, log *logrus.Entry,
+) (bool, error) {
+	if strings.EqualFold(reviewer, pr.Author) {
+		return false, nil
+	}
+
+	v, err := bot.hasPermission(reviewer, pr, log)
+	if err != nil || !v {
+		return false, err
+	}
+
+	// the email of reviewer is unknown, so only the login is compared.
+	isAuthor, err := bot.isCommitAuthor(cfg, pr, reviewer, "")
+	if err != nil {
+		return false, err
+	}
+
+	return !isAuthor, nil
+}
+
+// checkNativeReview checks the review and test passes on gitee, so that the bot doesn't
+// try to merge the PR which gitee refuses. The numbers required by gitee are respected,
+// and at least one tester is required if the test pass is required by the bot.
+func (m *mergeHelper) checkNativeReview(log *logrus.Entry) mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkNativeReview, nil)}
+
+	if !m.cfg.NativeReview.enabled() {
+		c.Passed = true
+
+		return c
+	}
+
+	v, err := m.cli.GetGiteePullRequest(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		log.WithError(err).Error("get the reviewers and testers of pr")
+		c.Detail = m.cfg.message(msgNativeReviewUnknown, nil)
+
+		return c
+	}
+
+	var reviewers, testers []string
+	if m.pushes != nil {
+		reviewers, testers = m.pushes.freshPasses(genPRKey(m.org, m.repo, m.pr.Number), &v)
+	} else {
+		reviewers, testers = getNativePasses(&v)
+	}
+
+	var details []string
+
+	if required := int(v.AssigneesNumber); len(reviewers) < required {
+		details = append(details, m.cfg.message(
+			msgReviewNotPassed, msgData{"Required": required, "Current": len(reviewers)},
+		))
+	}
+
+	required := int(v.TestersNumber)
+	if required < 1 && m.cfg.NativeReview.RequireTestPass {
+		required = 1
+	}
+
+	if len(testers) < required {
+		details = append(details, m.cfg.message(
+			msgTestNotPassed, msgData{"Required": required, "Current": len(testers)},
+		))
+	}
+
+	if c.Passed = len(details) == 0; !c.Passed {
+		c.Detail = strings.Join(details, " ")
+	}
+
+	return c
+}
+
+// getNativePasses returns the reviewers and testers who pass the PR on gitee.
+func getNativePasses(pr *sdk.PullRequest) (reviewers, testers []string) {
+	for i := range pr.Assignees {
+		if item := &pr.Assignees[i]; item.Accept {
+			reviewers = append(reviewers, item.Login)
+		}
+	}
+
+	for i := range pr.Testers {
+		if item := &pr.Testers[i]; item.Accept {
+			testers = append(testers, item.Login)
+		}
+	}
+
+	return
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestHandleNativeReview(t *testing.T) {
+	assign := func(login string, accept bool) sdk.PrAssign {
+		return sdk.PrAssign{Login: login, Accept: accept}
+	}
+
+	cases := []struct {
+		name      string
+		review    nativeReviewConfig
+		count     uint
+		labels    []string
+		assignees []sdk.PrAssign
+		stale     bool
+		added     []string
+	}{
+		{
+			name:   "review as lgtm",
+			review: nativeReviewConfig{ReviewAsLgtm: true},
+			count:  2,
+			assignees: []sdk.PrAssign{
+				assign("alice", true),
+				// no permission.
+				assign("bob", true),
+				assign("author", true),
+				assign("carol", false),
+			},
+			added: []string{"lgtm-alice"},
+		},
+		{
+			name:      "lgtm exists",
+			review:    nativeReviewConfig{ReviewAsLgtm: true},
+			count:     2,
+			labels:    []string{"lgtm-alice"},
+			assignees: []sdk.PrAssign{assign("alice", true)},
+		},
+		{
+			name:      "shared lgtm added once",
+			review:    nativeReviewConfig{ReviewAsLgtm: true},
+			count:     1,
+			assignees: []sdk.PrAssign{assign("alice", true), assign("carol", true)},
+			added:     []string{lgtmLabel},
+		},
+		{
+			name:      "passed before the push",
+			review:    nativeReviewConfig{ReviewAsLgtm: true},
+			count:     2,
+			assignees: []sdk.PrAssign{assign("alice", true)},
+			stale:     true,
+		},
+		{
+			name:      "test pass only",
+			review:    nativeReviewConfig{RequireTestPass: true},
+			count:     2,
+			assignees: []sdk.PrAssign{assign("alice", true)},
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{LgtmCountsRequired: tc.count, NativeReview: tc.review}
+		cfg.setDefault()
+
+		cli := newFakeClient()
+		cli.permissions = map[string]string{"alice": "write", "author": "write", "carol": "write"}
+		cli.pr = sdk.PullRequest{Number: 1, State: "open", Assignees: tc.assignees}
+
+		bot := newTestRobot(cli)
+		if tc.stale {
+			bot.pushes.setStalePasses(genPRKey("org", "repo", 1), &cli.pr)
+		}
+
+		e := newTestNoteEvent("", "", tc.labels...)
+		err := bot.handleNativeReview(
+			&sdk.PullRequestEvent{PullRequest: e.PullRequest, Repository: e.Repository}, cfg, newTestLog(),
+		)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if strings.Join(cli.added, ",") != strings.Join(tc.added, ",") {
+			t.Errorf("%s: added = %v, want %v", tc.name, cli.added, tc.added)
+		}
+	}
+}
+
+func TestCheckNativeReview(t *testing.T) {
+	cases := []struct {
+		name     string
+		review   nativeReviewConfig
+		pr       sdk.PullRequest
+		passed   bool
+		reviewed bool
+		tested   bool
+	}{
+		{
+			name:   "disabled",
+			pr:     sdk.PullRequest{AssigneesNumber: 1},
+			passed: true,
+		},
+		{
+			name:   "passed",
+			review: nativeReviewConfig{ReviewAsLgtm: true},
+			pr: sdk.PullRequest{
+				AssigneesNumber: 1,
+				Assignees:       []sdk.PrAssign{{Login: "alice", Accept: true}},
+			},
+			passed: true,
+		},
+		{
+			name:     "review not passed",
+			review:   nativeReviewConfig{ReviewAsLgtm: true},
+			pr:       sdk.PullRequest{AssigneesNumber: 2, Assignees: []sdk.PrAssign{{Login: "alice", Accept: true}}},
+			reviewed: true,
+		},
+		{
+			// at least one tester is required even if gitee requires none.
+			name:   "test not passed",
+			review: nativeReviewConfig{RequireTestPass: true},
+			pr:     sdk.PullRequest{Testers: []sdk.PrAssign{{Login: "alice"}}},
+			tested: true,
+		},
+		{
+			name:   "test passed",
+			review: nativeReviewConfig{RequireTestPass: true},
+			pr:     sdk.PullRequest{Testers: []sdk.PrAssign{{Login: "alice", Accept: true}}},
+			passed: true,
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{NativeReview: tc.review}
+		cfg.setDefault()
+
+		cli := newFakeClient()
+		cli.pr = tc.pr
+
+		m := mergeHelper{
+			cfg:  cfg,
+			org:  "org",
+			repo: "repo",
+			cli:  cli,
+			pr:   &sdk.PullRequestHook{Number: 1},
+		}
+
+		c := m.checkNativeReview(newTestLog())
+		if c.Passed != tc.passed {
+			t.Errorf("%s: passed = %t, want %t: %s", tc.name, c.Passed, tc.passed, c.Detail)
+		}
+
+		reviewed := strings.Contains(c.Detail, cfg.message(msgReviewNotPassed, msgData{"Required": 2, "Current": 1}))
+		if reviewed != tc.reviewed {
+			t.Errorf("%s: detail = %q", tc.name, c.Detail)
+		}
+
+		tested := strings.Contains(c.Detail, cfg.message(msgTestNotPassed, msgData{"Required": 1, "Current": 0}))
+		if tested != tc.tested {
+			t.Errorf("%s: detail = %q", tc.name, c.Detail)
+		}
+	}
+}
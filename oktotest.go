@@ -0,0 +1,164 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	okToTestLabel      = "ok-to-test"
+	needsOkToTestLabel = "needs-ok-to-test"
+
+	// contributorsTTL is how long the authors of merged PRs of a repo are cached
+	// before they are listed again for an author who is not found.
+	contributorsTTL = time.Hour
+)
+
+// checkOkToTest marks the PR of untrusted contributor as needing ok-to-test when it is opened.
+func (bot *robot) checkOkToTest(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	if !cfg.CITrigger.RequireOkToTest || giteeclient.GetPullRequestAction(e) != giteeclient.PRActionOpened {
+		return nil
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+	if pr.Labels.Has(okToTestLabel) {
+		return nil
+	}
+
+	v, err := bot.isTrustedContributor(pr, cfg, log)
+	if err != nil || v {
+		return err
+	}
+
+	return bot.markNeedsOkToTest(pr, cfg, true)
+}
+
+func (bot *robot) markNeedsOkToTest(pr giteeclient.PRInfo, cfg *botConfig, addComment bool) error {
+	if pr.Labels.Has(needsOkToTestLabel) {
+		return nil
+	}
+
+	if err := bot.createLabelIfNeed(pr.Org, pr.Repo, needsOkToTestLabel); err != nil {
+		return err
+	}
+
+	if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, needsOkToTestLabel); err != nil {
+		return err
+	}
+
+	if !addComment {
+		return nil
+	}
+
+	return bot.cli.CreatePRComment(
+		pr.Org, pr.Repo, pr.Number,
+		cfg.message(commentNeedsOkToTest, msgData{"Author": pr.Author}),
+	)
+}
+
+func (bot *robot) isTrustedContributor(pr giteeclient.PRInfo, cfg *botConfig, log *logrus.Entry) (bool, error) {
+	v, err := bot.hasPermission(pr.Author, pr, log)
+	if err != nil || v {
+		return v, err
+	}
+
+	if !cfg.CITrigger.TrustPriorContributors {
+		return false, nil
+	}
+
+	return bot.contributors.has(pr.Org, pr.Repo, pr.Author, func() ([]sdk.PullRequest, error) {
+		return bot.cli.GetPullRequests(pr.Org, pr.Repo, giteeclient.ListPullRequestOpt{State: "merged"})
+	})
+}
+
+// contributorCache caches the authors of merged PRs of each repo, because gitee can't
+// list the merged PRs of an author and listing all of them is expensive. An author who
+// has been found is kept, since a merged PR stays merged.
+type contributorCache struct {
+	repos map[string]*repoContributors
+	lock  sync.Mutex
+}
+
+type repoContributors struct {
+	authors  sets.String
+	listedAt time.Time
+}
+
+func newContributorCache() *contributorCache {
+	return &contributorCache{repos: map[string]*repoContributors{}}
+}
+
+// has reports whether login is an author of the merged PRs of repo. The merged PRs
+// are listed by list only if the author is not found and the cache is expired.
+func (c *contributorCache) has(org, repo, login string, list func() ([]sdk.PullRequest, error)) (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := org + "/" + repo
+	login = strings.ToLower(login)
+
+	v := c.repos[key]
+	if v != nil && (v.authors.Has(login) || time.Since(v.listedAt) < contributorsTTL) {
+		return v.authors.Has(login), nil
+	}
+
+	prs, err := list()
+	if err != nil {
+		return false, err
+	}
+
+	v = &repoContributors{authors: sets.NewString(), listedAt: time.Now()}
+	for i := range prs {
+		if u := prs[i].User; u != nil {
+			v.authors.Insert(strings.ToLower(u.Login))
+		}
+	}
+
+	c.repos[key] = v
+
+	return v.authors.Has(login), nil
+}
+
+// okToTest allows the CI to run for the PR of untrusted contributor and triggers it.
+func (bot *robot) okToTest(e giteeclient.PRNoteEvent, cfg *botConfig, log *logrus.Entry) error {
+	pr := e.GetPRInfo()
+	commenter := e.GetCommenter()
+
+	if pr.Labels.Has(okToTestLabel) {
+		return nil
+	}
+
+	if err := bot.createLabelIfNeed(pr.Org, pr.Repo, okToTestLabel); err != nil {
+		log.WithError(err).Errorf("create repo label: %s", okToTestLabel)
+	}
+
+	if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, okToTestLabel); err != nil {
+		return err
+	}
+
+	if pr.Labels.Has(needsOkToTestLabel) {
+		if err := bot.cli.RemovePRLabel(pr.Org, pr.Repo, pr.Number, needsOkToTestLabel); err != nil {
+			log.WithError(err).Errorf("remove label: %s", needsOkToTestLabel)
+		}
+	}
+
+	err := bot.cli.CreatePRComment(
+		pr.Org, pr.Repo, pr.Number,
+		cfg.message(commentAddLabel, msgData{"Label": okToTestLabel, "Commenter": commenter}),
+	)
+	if err != nil {
+		log.Error(err)
+	}
+
+	if cfg.CITrigger.Disabled {
+		return nil
+	}
+
+	return bot.triggerCI(pr, &cfg.CITrigger)
+}
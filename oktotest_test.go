@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestIsTrustedContributor(t *testing.T) {
+	merged := []sdk.PullRequest{{Number: 1, State: "merged", User: &sdk.UserBasic{Login: "Bob"}}}
+
+	cases := []struct {
+		name   string
+		author string
+		prior  bool
+		want   bool
+	}{
+		{name: "collaborator", author: "alice", want: true},
+		{name: "prior contributor", author: "bob", prior: true, want: true},
+		{name: "prior contributor not trusted", author: "bob"},
+		{name: "stranger", author: "carol", prior: true},
+	}
+
+	for _, tc := range cases {
+		cli := newFakeClient()
+		cli.permissions = map[string]string{"alice": "write"}
+		cli.prs = merged
+
+		bot := newTestRobot(cli)
+		cfg := &botConfig{CITrigger: ciTriggerConfig{RequireOkToTest: true, TrustPriorContributors: tc.prior}}
+		pr := giteeclient.PRInfo{Org: "org", Repo: "repo", Number: 2, Author: tc.author}
+
+		// the merged PRs are listed once for the repo.
+		for i := 0; i < 2; i++ {
+			v, err := bot.isTrustedContributor(pr, cfg, newTestLog())
+			if err != nil {
+				t.Fatalf("%s: %v", tc.name, err)
+			}
+
+			if v != tc.want {
+				t.Errorf("%s: trusted = %t, want %t", tc.name, v, tc.want)
+			}
+		}
+
+		if n := cli.calls["GetPullRequests"]; n > 1 {
+			t.Errorf("%s: the merged prs are listed %d times", tc.name, n)
+		}
+	}
+}
+
+func TestMarkNeedsOkToTest(t *testing.T) {
+	cases := []struct {
+		name    string
+		labels  []string
+		comment bool
+		added   bool
+	}{
+		{name: "marked", comment: true, added: true},
+		{name: "marked by reconciler", added: true},
+		{name: "already marked", labels: []string{needsOkToTestLabel}, comment: true},
+	}
+
+	cfg := &botConfig{}
+	cfg.setDefault()
+
+	for _, tc := range cases {
+		cli := newFakeClient()
+		bot := newTestRobot(cli)
+
+		pr := giteeclient.PRInfo{Org: "org", Repo: "repo", Number: 1, Author: "bob", Labels: sets.NewString(tc.labels...)}
+
+		if err := bot.markNeedsOkToTest(pr, cfg, tc.comment); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if added := strings.Join(cli.added, ",") == needsOkToTestLabel; added != tc.added {
+			t.Errorf("%s: added = %v", tc.name, cli.added)
+		}
+
+		if commented := len(cli.created) > 0; commented != (tc.added && tc.comment) {
+			t.Errorf("%s: comments = %q", tc.name, cli.created)
+		}
+	}
+}
+
+func TestOkToTest(t *testing.T) {
+	cfg := &botConfig{CITrigger: ciTriggerConfig{RequireOkToTest: true}}
+	cfg.setDefault()
+
+	cli := newFakeClient()
+	bot := newTestRobot(cli)
+
+	e := giteeclient.NewPRNoteEvent(newTestNoteEvent("alice", "/ok-to-test", needsOkToTestLabel))
+	if err := bot.okToTest(e, cfg, newTestLog()); err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(cli.added, ",") != okToTestLabel || strings.Join(cli.removed, ",") != needsOkToTestLabel {
+		t.Errorf("added = %v, removed = %v", cli.added, cli.removed)
+	}
+
+	// the ci is triggered by the default comment.
+	if n := len(cli.created); n != 2 || cli.created[1] != retestCommand {
+		t.Errorf("comments = %q", cli.created)
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// pathGlob matches the path of file with a glob pattern, in which ** matches
+// any number of directories, * and ? match the characters except /.
+// The pattern without / matches the name of file in any directory, such as *.spec.
+type pathGlob struct {
+	pattern string
+	reg     *regexp.Regexp
+}
+
+func newPathGlob(pattern string) (pathGlob, error) {
+	p := strings.TrimPrefix(pattern, "/")
+	if p == "" {
+		return pathGlob{}, fmt.Errorf("empty path pattern")
+	}
+
+	if !strings.Contains(p, "/") {
+		p = "**/" + p
+	}
+
+	b := strings.Builder{}
+	b.WriteString("^")
+
+	// iterate over the runes, so that the non-ASCII characters are kept.
+	runes := []rune(p)
+
+	for i := 0; i < len(runes); i++ {
+		switch c := runes[i]; c {
+		case '*':
+			if i+1 < len(runes) && runes[i+1] == '*' {
+				if i+2 < len(runes) && runes[i+2] == '/' {
+					b.WriteString("(?:.*/)?")
+					i += 2
+				} else {
+					b.WriteString(".*")
+					i++
+				}
+			} else {
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	b.WriteString("$")
+
+	reg, err := regexp.Compile(b.String())
+	if err != nil {
+		return pathGlob{}, fmt.Errorf("invalid path pattern:%s, err:%s", pattern, err.Error())
+	}
+
+	return pathGlob{pattern: pattern, reg: reg}, nil
+}
+
+func (g pathGlob) match(file string) bool {
+	return g.reg != nil && g.reg.MatchString(file)
+}
+
+func newPathGlobs(patterns []string) ([]pathGlob, error) {
+	r := make([]pathGlob, 0, len(patterns))
+
+	for _, p := range patterns {
+		g, err := newPathGlob(p)
+		if err != nil {
+			return nil, err
+		}
+
+		r = append(r, g)
+	}
+
+	return r, nil
+}
+
+func matchAnyPath(globs []pathGlob, file string) bool {
+	for _, g := range globs {
+		if g.match(file) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// handleChangesLabels labels the PR with its size and the paths of changed files
+// when it is opened or its source branch is changed.
+func (bot *robot) handleChangesLabels(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	if action := giteeclient.GetPullRequestAction(e); action != giteeclient.PRActionOpened &&
+		action != giteeclient.PRActionChangedSourceBranch {
+		return nil
+	}
+
+	pr := giteeclient.GetPRInfoByPREvent(e)
+
+	return bot.syncChangesLabels(pr, newPRChanges(bot.cli, pr), cfg, log)
+}
+
+// syncChangesLabels syncs the labels computed from the changed files which are fetched only once.
+func (bot *robot) syncChangesLabels(pr giteeclient.PRInfo, changes *prChanges, cfg *botConfig, log *logrus.Entry) error {
+	merr := utils.NewMultiErrors()
+
+	if err := bot.syncSizeLabel(pr, changes, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.syncPathLabels(pr, changes, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	return merr.Err()
+}
+
+// syncPathLabels labels the PR according to the paths of changed files.
+// The labels which no longer match are removed.
+func (bot *robot) syncPathLabels(pr giteeclient.PRInfo, changes *prChanges, cfg *botConfig, log *logrus.Entry) error {
+	if len(cfg.PathLabels) == 0 {
+		return nil
+	}
+
+	files, err := changes.get()
+	if err != nil {
+		return err
+	}
+
+	all := sets.NewString()
+	matched := sets.NewString()
+
+	for i := range cfg.PathLabels {
+		item := &cfg.PathLabels[i]
+		all.Insert(item.Label)
+
+		for j := range files {
+			if matchAnyPath(item.paths, files[j].Filename) {
+				matched.Insert(item.Label)
+
+				break
+			}
+		}
+	}
+
+	merr := utils.NewMultiErrors()
+
+	if v := all.Difference(matched).Intersection(pr.Labels); v.Len() > 0 {
+		if err := bot.cli.RemovePRLabels(pr.Org, pr.Repo, pr.Number, v.List()); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	for _, l := range matched.Difference(pr.Labels).List() {
+		if err := bot.createLabelIfNeed(pr.Org, pr.Repo, l); err != nil {
+			log.WithError(err).Errorf("create repo label: %s", l)
+		}
+
+		if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, l); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+// prChanges fetches the changed files of PR on the first use and caches them,
+// so that the handlers of the same event share one request.
+type prChanges struct {
+	cli iClient
+	pr  giteeclient.PRInfo
+
+	loaded bool
+	files  []sdk.PullRequestFiles
+	err    error
+}
+
+func newPRChanges(cli iClient, pr giteeclient.PRInfo) *prChanges {
+	return &prChanges{cli: cli, pr: pr}
+}
+
+func (c *prChanges) get() ([]sdk.PullRequestFiles, error) {
+	if !c.loaded {
+		c.files, c.err = c.cli.GetPullRequestChanges(c.pr.Org, c.pr.Repo, c.pr.Number)
+		c.loaded = true
+	}
+
+	return c.files, c.err
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestPathGlob(t *testing.T) {
+	cases := []struct {
+		pattern string
+		file    string
+		want    bool
+	}{
+		{pattern: "*.spec", file: "a.spec", want: true},
+		{pattern: "*.spec", file: "pkg/a.spec", want: true},
+		{pattern: "*.spec", file: "a.spec.bak"},
+		{pattern: "/docs/*.md", file: "docs/a.md", want: true},
+		{pattern: "docs/*.md", file: "docs/en/a.md"},
+		{pattern: "docs/**/*.md", file: "docs/a.md", want: true},
+		{pattern: "docs/**/*.md", file: "docs/en/zh/a.md", want: true},
+		{pattern: "docs/**", file: "docs/en/a.md", want: true},
+		{pattern: "src/?.go", file: "src/a.go", want: true},
+		{pattern: "src/?.go", file: "src/ab.go"},
+		{pattern: "a+b/(c).txt", file: "a+b/(c).txt", want: true},
+		{pattern: "文档/*.md", file: "文档/说明.md", want: true},
+		{pattern: "文档/?.md", file: "文档/说.md", want: true},
+		{pattern: "文档/*.md", file: "文件/说明.md"},
+	}
+
+	for _, tc := range cases {
+		g, err := newPathGlob(tc.pattern)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.pattern, err)
+		}
+
+		if v := g.match(tc.file); v != tc.want {
+			t.Errorf("%s: match(%q) = %v, want %v", tc.pattern, tc.file, v, tc.want)
+		}
+	}
+
+	if _, err := newPathGlob("/"); err == nil {
+		t.Error("the empty pattern is accepted")
+	}
+}
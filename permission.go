@@ -16,9 +16,19 @@ func (bot *robot) hasPermission(
 	commenter string,
 	pr giteeclient.PRInfo,
 	log *logrus.Entry,
+) (bool, error) {
+	return hasRepoPermission(bot.cli, commenter, pr, log)
+}
+
+// hasRepoPermission checks whether the user can write the repo or is one of its owners.
+func hasRepoPermission(
+	cli iClient,
+	commenter string,
+	pr giteeclient.PRInfo,
+	log *logrus.Entry,
 ) (bool, error) {
 	commenter = strings.ToLower(commenter)
-	p, err := bot.cli.GetUserPermissionsOfRepo(pr.Org, pr.Repo, commenter)
+	p, err := cli.GetUserPermissionsOfRepo(pr.Org, pr.Repo, commenter)
 	if err != nil {
 		return false, err
 	}
@@ -27,19 +37,20 @@ func (bot *robot) hasPermission(
 		return true, nil
 	}
 
-	if bot.isRepoOwners(commenter, pr, log) {
+	if isRepoOwners(cli, commenter, pr, log) {
 		return true, nil
 	}
 
 	return false, nil
 }
 
-func (bot *robot) isRepoOwners(
+func isRepoOwners(
+	cli iClient,
 	commenter string,
 	pr giteeclient.PRInfo,
 	log *logrus.Entry,
 ) bool {
-	v, err := bot.cli.GetPathContent(pr.Org, pr.Repo, ownerFile, pr.BaseRef)
+	v, err := cli.GetPathContent(pr.Org, pr.Repo, ownerFile, pr.BaseRef)
 	if err != nil {
 		log.Errorf(
 			"get file:%s/%s/%s:%s, err:%s",
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	needsIssueLabel   = "needs-issue"
+	invalidTitleLabel = "invalid-title"
+)
+
+// handlePRPolicy labels the PR whose title or description violates the policy of repo
+// when it is opened or edited, and removes the labels once it is corrected.
+func (bot *robot) handlePRPolicy(e *sdk.PullRequestEvent, cfg *botConfig, log *logrus.Entry) error {
+	// the labels are checked too when they are updated, because they decide
+	// whether the linked issue is required.
+	if giteeclient.GetPullRequestAction(e) == giteeclient.PRActionClosed {
+		return nil
+	}
+
+	v := e.GetPullRequest()
+	if v == nil || v.State != "open" {
+		return nil
+	}
+
+	return bot.syncPRPolicyLabels(giteeclient.GetPRInfoByPREvent(e), v.Title, v.Body, cfg, log)
+}
+
+func (bot *robot) syncPRPolicyLabels(
+	pr giteeclient.PRInfo, title, body string, cfg *botConfig, log *logrus.Entry,
+) error {
+	p := &cfg.PRPolicy
+
+	var toAdd, toRemove []string
+	set := func(label string, enabled, violated bool) {
+		switch {
+		case enabled && violated && !pr.Labels.Has(label):
+			toAdd = append(toAdd, label)
+		case (!enabled || !violated) && pr.Labels.Has(label):
+			toRemove = append(toRemove, label)
+		}
+	}
+
+	set(invalidTitleLabel, p.titlePattern != nil, !p.isTitleValid(title))
+	set(needsIssueLabel, p.requireLinkedIssue(pr.Labels), len(parseIssueRefs(body, pr.Org, pr.Repo)) == 0)
+
+	merr := utils.NewMultiErrors()
+
+	for _, l := range toAdd {
+		if err := bot.createLabelIfNeed(pr.Org, pr.Repo, l); err != nil {
+			log.WithError(err).Errorf("create repo label: %s", l)
+		}
+
+		if err := bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, l); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	if len(toRemove) > 0 {
+		if err := bot.cli.RemovePRLabels(pr.Org, pr.Repo, pr.Number, toRemove); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+// checkPRPolicy checks the title and description of PR against the policy of repo.
+func (m *mergeHelper) checkPRPolicy() mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkPRPolicy, nil)}
+
+	v := prPolicyViolations(m.cfg, m.pr.Title, m.pr.Body, m.org, m.repo, m.getLabels())
+	if c.Passed = len(v) == 0; !c.Passed {
+		c.Detail = strings.Join(v, " ")
+	}
+
+	return c
+}
+
+func prPolicyViolations(cfg *botConfig, title, body, org, repo string, labels sets.String) []string {
+	p := &cfg.PRPolicy
+
+	var r []string
+
+	if !p.isTitleValid(title) {
+		r = append(r, cfg.message(msgInvalidTitle, msgData{"Pattern": p.TitlePattern}))
+	}
+
+	var missing []string
+	for _, s := range p.RequiredSections {
+		if !strings.Contains(body, s) {
+			missing = append(missing, s)
+		}
+	}
+	if len(missing) > 0 {
+		r = append(r, cfg.message(msgMissingSections, msgData{"Sections": strings.Join(missing, ", ")}))
+	}
+
+	if n := uint(utf8.RuneCountInString(strings.TrimSpace(body))); n < p.MinDescriptionLength {
+		r = append(r, cfg.message(
+			msgDescriptionTooShort, msgData{"Required": p.MinDescriptionLength, "Current": n},
+		))
+	}
+
+	if p.requireLinkedIssue(labels) && len(parseIssueRefs(body, org, repo)) == 0 {
+		r = append(r, cfg.message(msgNeedsIssue, nil))
+	}
+
+	return r
+}
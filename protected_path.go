@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// checkProtectedPaths checks whether the changes of the protected paths
+// are approved by one of their approvers at least.
+func (m *mergeHelper) checkProtectedPaths(log *logrus.Entry) mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkProtectedPaths, nil)}
+
+	missing, err := m.getUnapprovedProtectedPaths(log)
+	if err != nil {
+		log.WithError(err).Error("check the approval of protected paths")
+		c.Detail = m.cfg.message(msgProtectedPathsUnknown, nil)
+
+		return c
+	}
+
+	if c.Passed = len(missing) == 0; !c.Passed {
+		c.Detail = strings.Join(missing, " ")
+	}
+
+	return c
+}
+
+// getUnapprovedProtectedPaths returns the messages of the protected paths
+// which are changed by the PR but still lack approval.
+func (m *mergeHelper) getUnapprovedProtectedPaths(log *logrus.Entry) ([]string, error) {
+	rules := m.cfg.ProtectedPaths
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	files, err := m.cli.GetPullRequestChanges(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	var matched []*protectedPath
+
+	for i := range rules {
+		for j := range files {
+			if matchAnyPath(rules[i].paths, files[j].Filename) {
+				matched = append(matched, &rules[i])
+
+				break
+			}
+		}
+	}
+
+	if len(matched) == 0 {
+		return nil, nil
+	}
+
+	candidates := sets.NewString()
+	for _, p := range matched {
+		candidates = candidates.Union(p.approvers)
+	}
+
+	approvers, err := m.getApprovers(candidates, log)
+	if err != nil {
+		return nil, err
+	}
+
+	var r []string
+
+	for _, p := range matched {
+		if approvers.HasAny(p.approvers.UnsortedList()...) {
+			continue
+		}
+
+		r = append(r, m.cfg.message(msgProtectedPathNeedsApproval, msgData{
+			"Paths":     strings.Join(p.Paths, ", "),
+			"Approvers": strings.Join(p.Approvers, ", "),
+		}))
+	}
+
+	return r, nil
+}
+
+// getApprovers returns the candidates whose /approve are applied by the bot after
+// the last push of PR and are not cancelled later. The comments which the bot refuses,
+// such as the ones without permission or the self approval when it is forbidden, are
+// ignored, and none is returned if the PR is not labeled with approved.
+func (m *mergeHelper) getApprovers(candidates sets.String, log *logrus.Entry) (sets.String, error) {
+	r := sets.NewString()
+
+	if !m.getLabels().Has(approvedLabel) {
+		return r, nil
+	}
+
+	pr, err := m.cli.GetGiteePullRequest(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := m.lastPushTime(&pr)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := m.cli.ListPRComments(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	author := strings.ToLower(getPRAuthor(&pr))
+
+	for i := range comments {
+		c := &comments[i]
+		if c.User == nil {
+			continue
+		}
+
+		if t, err := time.Parse(time.RFC3339, c.CreatedAt); err != nil || t.Before(last) {
+			continue
+		}
+
+		login := strings.ToLower(c.User.Login)
+		if !candidates.Has(login) || (login == author && m.cfg.SelfApprovalPolicy == selfApprovalForbid) {
+			continue
+		}
+
+		for _, cmd := range parseCommands(c.Body) {
+			if args, ok := cmd.match("approve"); ok && len(args) == 0 {
+				r.Insert(login)
+			} else if args, ok := cmd.match("approve cancel"); ok && len(args) == 0 {
+				r.Delete(login)
+			}
+		}
+	}
+
+	info := getPRInfo(m.org, m.repo, &pr)
+
+	for _, login := range r.UnsortedList() {
+		v, err := hasRepoPermission(m.cli, login, info, log)
+		if err != nil {
+			return nil, err
+		}
+
+		if !v {
+			r.Delete(login)
+		}
+	}
+
+	return r, nil
+}
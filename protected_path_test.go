@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestGetUnapprovedProtectedPaths(t *testing.T) {
+	comment := func(login, body, at string) sdk.PullRequestComments {
+		return sdk.PullRequestComments{User: &sdk.UserBasic{Login: login}, Body: body, CreatedAt: at}
+	}
+
+	cases := []struct {
+		name     string
+		labels   []string
+		pushed   time.Time
+		policy   selfApprovalPolicy
+		comments []sdk.PullRequestComments
+		missing  bool
+	}{
+		{
+			name:     "approved",
+			labels:   []string{approvedLabel},
+			comments: []sdk.PullRequestComments{comment("alice", "/approve", "2021-06-01T12:00:00+08:00")},
+		},
+		{
+			// the update of PR which is later than the comment is not taken as the push.
+			name:     "push unknown",
+			labels:   []string{approvedLabel},
+			comments: []sdk.PullRequestComments{comment("alice", "/approve", "2021-06-01T09:00:00+08:00")},
+		},
+		{
+			name:     "approved before push",
+			labels:   []string{approvedLabel},
+			pushed:   time.Date(2021, 6, 1, 6, 0, 0, 0, time.UTC),
+			comments: []sdk.PullRequestComments{comment("alice", "/approve", "2021-06-01T12:00:00+08:00")},
+			missing:  true,
+		},
+		{
+			name:   "cancelled",
+			labels: []string{approvedLabel},
+			comments: []sdk.PullRequestComments{
+				comment("alice", "/approve", "2021-06-01T12:00:00+08:00"),
+				comment("alice", "/approve cancel", "2021-06-01T13:00:00+08:00"),
+			},
+			missing: true,
+		},
+		{
+			name:     "not approver",
+			labels:   []string{approvedLabel},
+			comments: []sdk.PullRequestComments{comment("bob", "/approve", "2021-06-01T12:00:00+08:00")},
+			missing:  true,
+		},
+		{
+			name:     "no permission",
+			labels:   []string{approvedLabel},
+			comments: []sdk.PullRequestComments{comment("carol", "/approve", "2021-06-01T12:00:00+08:00")},
+			missing:  true,
+		},
+		{
+			name:     "self approval forbidden",
+			labels:   []string{approvedLabel},
+			policy:   selfApprovalForbid,
+			comments: []sdk.PullRequestComments{comment("author", "/approve", "2021-06-01T12:00:00+08:00")},
+			missing:  true,
+		},
+		{
+			name:     "not labeled",
+			comments: []sdk.PullRequestComments{comment("alice", "/approve", "2021-06-01T12:00:00+08:00")},
+			missing:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		cfg := &botConfig{
+			SelfApprovalPolicy: tc.policy,
+			ProtectedPaths: []protectedPath{
+				{Paths: []string{"OWNERS"}, Approvers: []string{"alice", "author", "carol"}},
+			},
+		}
+		cfg.setDefault()
+		if err := cfg.validate(); err != nil {
+			t.Fatal(err)
+		}
+
+		cli := newFakeClient()
+		cli.permissions = map[string]string{"alice": "write", "author": "write", "bob": "write"}
+		cli.files = []sdk.PullRequestFiles{{Filename: "OWNERS"}}
+		cli.comments = tc.comments
+		cli.pr = sdk.PullRequest{
+			Number:    1,
+			CreatedAt: "2021-06-01T08:00:00+08:00",
+			UpdatedAt: "2021-06-02T08:00:00+08:00",
+			User:      &sdk.UserBasic{Login: "author"},
+		}
+
+		var labels []sdk.LabelHook
+		for _, l := range tc.labels {
+			labels = append(labels, sdk.LabelHook{Name: l})
+		}
+
+		m := mergeHelper{
+			cfg:    cfg,
+			org:    "org",
+			repo:   "repo",
+			cli:    cli,
+			pushes: newPushTracker(),
+			pr:     &sdk.PullRequestHook{Number: 1, Labels: labels},
+		}
+
+		if !tc.pushed.IsZero() {
+			m.pushes.set(genPRKey("org", "repo", 1), tc.pushed)
+		}
+
+		v, err := m.getUnapprovedProtectedPaths(newTestLog())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if missing := len(v) > 0; missing != tc.missing {
+			t.Errorf("%s: missing = %t, want %t: %s", tc.name, missing, tc.missing, strings.Join(v, " "))
+		}
+	}
+}
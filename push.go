@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// pushTracker records the time when the source branch of PR was pushed lastly.
+// gitee doesn't provide it, and the committer date of commits can't be trusted
+// because it is set by the committer, so that it is used only when the push is unknown.
+//
+// It also records the passes on gitee before the last push, because gitee keeps them
+// after the push and doesn't tell when they are made.
+type pushTracker struct {
+	times map[string]time.Time
+
+	// stale is the set of reviewers and testers who passed the PR before the last push.
+	stale map[string]sets.String
+
+	lock sync.Mutex
+}
+
+func newPushTracker() *pushTracker {
+	return &pushTracker{
+		times: map[string]time.Time{},
+		stale: map[string]sets.String{},
+	}
+}
+
+// track records the push time of PR when the event is received, and forgets it when the PR is closed.
+func (t *pushTracker) track(e *sdk.PullRequestEvent) {
+	pr := giteeclient.GetPRInfoByPREvent(e)
+	key := genPRKey(pr.Org, pr.Repo, pr.Number)
+
+	switch giteeclient.GetPullRequestAction(e) {
+	case giteeclient.PRActionChangedSourceBranch:
+		t.set(key, time.Now())
+
+	case giteeclient.PRActionClosed:
+		t.lock.Lock()
+		delete(t.times, key)
+		delete(t.stale, key)
+		t.lock.Unlock()
+	}
+}
+
+func (t *pushTracker) set(key string, at time.Time) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if at.After(t.times[key]) {
+		t.times[key] = at
+	}
+}
+
+func (t *pushTracker) get(key string) (time.Time, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	v, ok := t.times[key]
+
+	return v, ok
+}
+
+// setStalePasses records the passes of PR which are made before the push.
+func (t *pushTracker) setStalePasses(key string, pr *sdk.PullRequest) {
+	reviewers, testers := getNativePasses(pr)
+
+	v := sets.NewString()
+	for _, login := range reviewers {
+		v.Insert(reviewerKey(login))
+	}
+
+	for _, login := range testers {
+		v.Insert(testerKey(login))
+	}
+
+	t.lock.Lock()
+	t.stale[key] = v
+	t.lock.Unlock()
+}
+
+// freshPasses returns the passes of PR except the ones made before the last push.
+// The user who is seen not passing any more is removed from the stale ones, so that
+// the pass made again counts. The passes are all fresh if the bot restarts after the push.
+func (t *pushTracker) freshPasses(key string, pr *sdk.PullRequest) (reviewers, testers []string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	stale := t.stale[key]
+
+	filter := func(items []sdk.PrAssign, genKey func(string) string) []string {
+		var r []string
+
+		for i := range items {
+			k := genKey(items[i].Login)
+
+			if !items[i].Accept {
+				stale.Delete(k)
+			} else if !stale.Has(k) {
+				r = append(r, items[i].Login)
+			}
+		}
+
+		return r
+	}
+
+	return filter(pr.Assignees, reviewerKey), filter(pr.Testers, testerKey)
+}
+
+func reviewerKey(login string) string {
+	return "reviewer/" + strings.ToLower(login)
+}
+
+func testerKey(login string) string {
+	return "tester/" + strings.ToLower(login)
+}
+
+// lastPushTime returns the time when the source branch of PR was pushed lastly.
+// If it is unknown, for example the bot restarts, the committer date of the head
+// commit is used instead, but never earlier than the time when the PR was created.
+// The time when the PR was updated can't be used, because comments and labels change it too.
+func (m *mergeHelper) lastPushTime(pr *sdk.PullRequest) (time.Time, error) {
+	if m.pushes != nil {
+		if t, ok := m.pushes.get(genPRKey(m.org, m.repo, m.pr.Number)); ok {
+			return t, nil
+		}
+	}
+
+	created, err := time.Parse(time.RFC3339, pr.CreatedAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	commits, err := m.cli.GetPRCommits(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if t := headCommitTime(commits, m.pr.GetHead().GetSha()); t.After(created) {
+		return t, nil
+	}
+
+	return created, nil
+}
+
+// headCommitTime returns the committer date of the head commit, which is the last one
+// if sha is not found. It returns the zero time if the date is unknown.
+func headCommitTime(commits []sdk.PullRequestCommits, sha string) time.Time {
+	if len(commits) == 0 {
+		return time.Time{}
+	}
+
+	c := &commits[len(commits)-1]
+	for i := range commits {
+		if commits[i].Sha == sha {
+			c = &commits[i]
+
+			break
+		}
+	}
+
+	if c.Commit == nil || c.Commit.Committer == nil {
+		return time.Time{}
+	}
+
+	t, _ := time.Parse(time.RFC3339, c.Commit.Committer.Date)
+
+	return t
+}
+
+func genPRKey(org, repo string, number int32) string {
+	return fmt.Sprintf("%s/%s/%d", org, repo, number)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestLastPushTime(t *testing.T) {
+	created := "2021-06-01T08:00:00+08:00"
+	updated := "2021-06-03T10:00:00+08:00"
+	pushed := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	pushes := newPushTracker()
+	pushes.set(genPRKey("org", "repo", 1), pushed)
+	// the earlier time is ignored.
+	pushes.set(genPRKey("org", "repo", 1), pushed.Add(-time.Hour))
+
+	commit := func(sha, date string) sdk.PullRequestCommits {
+		return sdk.PullRequestCommits{
+			Sha:    sha,
+			Commit: &sdk.PullRequestCommitsCommit{Committer: &sdk.GitUserBasic{Date: date}},
+		}
+	}
+
+	cases := []struct {
+		name    string
+		number  int32
+		commits []sdk.PullRequestCommits
+		want    string
+	}{
+		{name: "tracked", number: 1, want: pushed.Format(time.RFC3339)},
+		{
+			name:   "head commit",
+			number: 2,
+			commits: []sdk.PullRequestCommits{
+				commit("head", "2021-06-02T10:00:00+08:00"),
+				commit("c2", "2021-06-02T12:00:00+08:00"),
+			},
+			want: "2021-06-02T10:00:00+08:00",
+		},
+		{
+			name:   "last commit",
+			number: 2,
+			commits: []sdk.PullRequestCommits{
+				commit("c1", "2021-06-02T09:00:00+08:00"),
+				commit("c2", "2021-06-02T11:00:00+08:00"),
+			},
+			want: "2021-06-02T11:00:00+08:00",
+		},
+		{
+			name:   "committed before created",
+			number: 2,
+			commits: []sdk.PullRequestCommits{
+				commit("head", "2021-05-01T10:00:00+08:00"),
+			},
+			want: created,
+		},
+		{name: "no commit", number: 2, want: created},
+	}
+
+	for _, tc := range cases {
+		cli := newFakeClient()
+		cli.commits = tc.commits
+
+		m := mergeHelper{
+			org:    "org",
+			repo:   "repo",
+			cli:    cli,
+			pushes: pushes,
+			pr:     &sdk.PullRequestHook{Number: tc.number, Head: &sdk.BranchHook{Sha: "head"}},
+		}
+
+		v, err := m.lastPushTime(&sdk.PullRequest{CreatedAt: created, UpdatedAt: updated})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if s := v.Format(time.RFC3339); s != tc.want {
+			t.Errorf("%s: lastPushTime = %s, want %s", tc.name, s, tc.want)
+		}
+	}
+}
+
+func TestFreshPasses(t *testing.T) {
+	pushes := newPushTracker()
+	key := genPRKey("org", "repo", 1)
+
+	pr := &sdk.PullRequest{
+		Assignees: []sdk.PrAssign{{Login: "alice", Accept: true}, {Login: "bob"}},
+		Testers:   []sdk.PrAssign{{Login: "carol", Accept: true}},
+	}
+
+	// the passes are fresh before any push is seen.
+	if r, ts := pushes.freshPasses(key, pr); len(r) != 1 || len(ts) != 1 {
+		t.Fatalf("reviewers = %v, testers = %v", r, ts)
+	}
+
+	pushes.setStalePasses(key, pr)
+
+	// bob passes after the push, and alice cancels her pass.
+	pr.Assignees = []sdk.PrAssign{{Login: "alice"}, {Login: "bob", Accept: true}}
+	if r, ts := pushes.freshPasses(key, pr); len(r) != 1 || r[0] != "bob" || len(ts) != 0 {
+		t.Fatalf("reviewers = %v, testers = %v", r, ts)
+	}
+
+	// alice passes again.
+	pr.Assignees[0].Accept = true
+	if r, _ := pushes.freshPasses(key, pr); len(r) != 2 {
+		t.Fatalf("reviewers = %v", r)
+	}
+}
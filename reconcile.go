@@ -0,0 +1,388 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/opensourceways/community-robot-lib/utils"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+type reconcilerOptions struct {
+	interval    time.Duration
+	concurrency int
+}
+
+func (o *reconcilerOptions) addFlags(fs *flag.FlagSet) {
+	fs.DurationVar(&o.interval, "reconcile-interval", 0, "The interval to check the open PRs and merge the ones which are ready, it is disabled when 0")
+	fs.IntVar(&o.concurrency, "reconcile-concurrency", 2, "The number of repos of an org that are reconciled concurrently")
+}
+
+func (o *reconcilerOptions) validate() error {
+	if o.interval < 0 {
+		return fmt.Errorf("reconcile-interval can't be negative")
+	}
+
+	if o.interval > 0 && o.concurrency <= 0 {
+		return fmt.Errorf("reconcile-concurrency must be positive")
+	}
+
+	return nil
+}
+
+// reconciler periodically checks the open PRs of all the configured repos
+// and merges the ones whose merge conditions are met, or syncs their labels
+// otherwise, in case that the webhooks were missed. It also handles the stale PRs.
+//
+// It works on the configuration which the plugin passes to the latest event,
+// so that it is always the same as the one of event handlers. As a result,
+// it does nothing until the first event is received after start.
+type reconciler struct {
+	bot *robot
+	opt reconcilerOptions
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newReconciler(bot *robot, opt reconcilerOptions) *reconciler {
+	return &reconciler{
+		bot:    bot,
+		opt:    opt,
+		stopCh: make(chan struct{}),
+	}
+}
+
+func (r *reconciler) start() {
+	if r.opt.interval <= 0 {
+		return
+	}
+
+	r.wg.Add(1)
+
+	go func() {
+		defer r.wg.Done()
+
+		t := time.NewTicker(r.opt.interval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-t.C:
+				r.reconcile()
+			}
+		}
+	}()
+}
+
+func (r *reconciler) stop() {
+	if r.opt.interval <= 0 {
+		return
+	}
+
+	close(r.stopCh)
+	r.wg.Wait()
+}
+
+func (r *reconciler) reconcile() {
+	log := logrus.WithField("component", "reconciler")
+
+	cfg := r.bot.latestConfig()
+	if cfg == nil {
+		log.Info("skip reconciling, no configuration is received yet")
+		return
+	}
+
+	repos := r.listRepos(cfg, log)
+
+	var wg sync.WaitGroup
+	for org, v := range repos {
+		wg.Add(1)
+
+		go func(org string, repos []string) {
+			defer wg.Done()
+
+			r.reconcileOrg(cfg, org, repos, log.WithField("org", org))
+		}(org, v)
+	}
+
+	wg.Wait()
+}
+
+// listRepos returns the repos of each org covered by the configuration.
+func (r *reconciler) listRepos(cfg *configuration, log *logrus.Entry) map[string][]string {
+	all := map[string]sets.String{}
+	add := func(org, repo string) {
+		if cfg.configFor(org, repo) == nil {
+			return
+		}
+
+		if v, ok := all[org]; ok {
+			v.Insert(repo)
+		} else {
+			all[org] = sets.NewString(repo)
+		}
+	}
+
+	for i := range cfg.ConfigItems {
+		for _, item := range cfg.ConfigItems[i].Repos {
+			if v := strings.Split(item, "/"); len(v) == 2 {
+				add(v[0], v[1])
+
+				continue
+			}
+
+			projects, err := r.bot.cli.GetRepos(item)
+			if err != nil {
+				log.WithError(err).Errorf("list repos of org: %s", item)
+
+				continue
+			}
+
+			for j := range projects {
+				add(item, projects[j].Path)
+			}
+		}
+	}
+
+	repos := make(map[string][]string, len(all))
+	for org, v := range all {
+		repos[org] = v.List()
+	}
+
+	return repos
+}
+
+func (r *reconciler) reconcileOrg(cfg *configuration, org string, repos []string, log *logrus.Entry) {
+	sem := make(chan struct{}, r.opt.concurrency)
+
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		bc := cfg.configFor(org, repo)
+		if bc == nil {
+			continue
+		}
+
+		sem <- struct{}{}
+		wg.Add(1)
+
+		go func(repo string, bc *botConfig) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			if err := r.bot.reconcileRepo(org, repo, bc, log.WithField("repo", repo)); err != nil {
+				log.WithError(err).Errorf("reconcile repo: %s", repo)
+			}
+		}(repo, bc)
+	}
+
+	wg.Wait()
+}
+
+func (bot *robot) reconcileRepo(org, repo string, cfg *botConfig, log *logrus.Entry) error {
+	prs, err := bot.cli.GetPullRequests(org, repo, giteeclient.ListPullRequestOpt{State: "open"})
+	if err != nil {
+		return err
+	}
+
+	merr := utils.NewMultiErrors()
+
+	for i := range prs {
+		if err := bot.reconcilePR(org, repo, &prs[i], cfg, log); err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	return merr.Err()
+}
+
+func (bot *robot) reconcilePR(org, repo string, pr *sdk.PullRequest, cfg *botConfig, log *logrus.Entry) error {
+	// the merge check and the label syncers share the PR, its commits and changed files.
+	cli := newPassClient(bot.cli, org, repo, pr)
+
+	h := mergeHelper{
+		cfg:    cfg,
+		org:    org,
+		repo:   repo,
+		cli:    cli,
+		pushes: bot.pushes,
+		pr:     convertToPRHook(pr),
+	}
+
+	if _, ok := h.canMerge(log); ok {
+		if err := h.merge(log); err != nil {
+			return err
+		}
+
+		log.Infof("merged pr: %d", pr.Number)
+
+		return nil
+	}
+
+	if err := bot.reconcileLabels(org, repo, pr, cli, cfg, log); err != nil {
+		log.WithError(err).Errorf("sync the labels of pr: %d", pr.Number)
+	}
+
+	return bot.handleStale(org, repo, pr, cfg, log)
+}
+
+// reconcileLabels syncs the labels which are maintained on the PR events.
+// The changes will be seen by the merge check of next round.
+func (bot *robot) reconcileLabels(
+	org, repo string, pr *sdk.PullRequest, cli *passClient, cfg *botConfig, log *logrus.Entry,
+) error {
+	info := getPRInfo(org, repo, pr)
+
+	// the syncers only call gitee by the client of bot.
+	b := &robot{cli: cli}
+
+	merr := utils.NewMultiErrors()
+
+	if cfg.TrackConflicts {
+		err := b.syncConflictLabel(org, repo, pr.Number, pr.Mergeable, info.Labels, info.Author, cfg, log)
+		if err != nil {
+			merr.AddError(err)
+		}
+	}
+
+	if err := b.syncPRPolicyLabels(info, pr.Title, pr.Body, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if _, err := b.syncDCOLabel(info, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := b.syncChangesLabels(info, newPRChanges(cli, info), cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	return merr.Err()
+}
+
+// passClient is the client used to reconcile a PR in one pass. It takes the PR from
+// the list of open PRs and fetches the commits and changed files of PR only once.
+type passClient struct {
+	iClient
+
+	org    string
+	repo   string
+	number int32
+	pr     sdk.PullRequest
+
+	commits       []sdk.PullRequestCommits
+	commitsErr    error
+	commitsLoaded bool
+
+	files       []sdk.PullRequestFiles
+	filesErr    error
+	filesLoaded bool
+}
+
+func newPassClient(cli iClient, org, repo string, pr *sdk.PullRequest) *passClient {
+	return &passClient{iClient: cli, org: org, repo: repo, number: pr.Number, pr: *pr}
+}
+
+func (c *passClient) isPR(org, repo string, number int32) bool {
+	return org == c.org && repo == c.repo && number == c.number
+}
+
+func (c *passClient) GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error) {
+	if !c.isPR(org, repo, number) {
+		return c.iClient.GetGiteePullRequest(org, repo, number)
+	}
+
+	return c.pr, nil
+}
+
+func (c *passClient) GetPRCommits(org, repo string, number int32) ([]sdk.PullRequestCommits, error) {
+	if !c.isPR(org, repo, number) {
+		return c.iClient.GetPRCommits(org, repo, number)
+	}
+
+	if !c.commitsLoaded {
+		c.commits, c.commitsErr = c.iClient.GetPRCommits(org, repo, number)
+		c.commitsLoaded = true
+	}
+
+	return c.commits, c.commitsErr
+}
+
+func (c *passClient) GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error) {
+	if !c.isPR(org, repo, number) {
+		return c.iClient.GetPullRequestChanges(org, repo, number)
+	}
+
+	if !c.filesLoaded {
+		c.files, c.filesErr = c.iClient.GetPullRequestChanges(org, repo, number)
+		c.filesLoaded = true
+	}
+
+	return c.files, c.filesErr
+}
+
+func getPRInfo(org, repo string, pr *sdk.PullRequest) giteeclient.PRInfo {
+	v := giteeclient.PRInfo{
+		Org:    org,
+		Repo:   repo,
+		Number: pr.Number,
+		Author: getPRAuthor(pr),
+		Labels: getPRLabels(pr),
+	}
+
+	if pr.Base != nil {
+		v.BaseRef = pr.Base.Ref
+	}
+
+	if pr.Head != nil {
+		v.HeadSHA = pr.Head.Sha
+	}
+
+	return v
+}
+
+// convertToPRHook converts the PR fetched by api to the one of webhook
+// which mergeHelper works on.
+func convertToPRHook(pr *sdk.PullRequest) *sdk.PullRequestHook {
+	v := &sdk.PullRequestHook{
+		Id:        pr.Id,
+		Number:    pr.Number,
+		State:     pr.State,
+		HtmlUrl:   pr.HtmlUrl,
+		Title:     pr.Title,
+		Body:      pr.Body,
+		Mergeable: pr.Mergeable,
+
+		// the review or test is needed when gitee requires any reviewer or tester.
+		NeedReview: pr.AssigneesNumber > 0,
+		NeedTest:   pr.TestersNumber > 0,
+	}
+
+	for _, l := range pr.Labels {
+		v.Labels = append(v.Labels, sdk.LabelHook{Id: l.Id, Name: l.Name, Color: l.Color})
+	}
+
+	if u := pr.User; u != nil {
+		v.User = &sdk.UserHook{Login: u.Login, Name: u.Name}
+	}
+
+	if b := pr.Base; b != nil {
+		v.Base = &sdk.BranchHook{Label: b.Label, Ref: b.Ref, Sha: b.Sha}
+	}
+
+	if h := pr.Head; h != nil {
+		v.Head = &sdk.BranchHook{Label: h.Label, Ref: h.Ref, Sha: h.Sha}
+	}
+
+	return v
+}
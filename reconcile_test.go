@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestReconcilePRFetchesOnce(t *testing.T) {
+	cfg := &botConfig{
+		CheckDCO:       true,
+		TrackConflicts: true,
+		ReviewPeriod:   reviewPeriodConfig{MinOpenDuration: "1h", SinceLastPush: true},
+		CommitRules:    commitRulesConfig{MaxCommits: 5},
+		Size:           sizeConfig{Enabled: true},
+		PathLabels:     []pathLabel{{Label: "docs", Paths: []string{"docs/**"}}},
+		ProtectedPaths: []protectedPath{{Paths: []string{"OWNERS"}, Approvers: []string{"alice"}}},
+	}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := sdk.PullRequest{
+		Number:    1,
+		State:     "open",
+		Mergeable: true,
+		CreatedAt: "2021-06-01T08:00:00+08:00",
+		UpdatedAt: "2021-06-01T08:00:00+08:00",
+		User:      &sdk.UserBasic{Login: "author"},
+		Labels:    []sdk.Label{{Name: approvedLabel}},
+	}
+
+	cli := newFakeClient()
+	cli.prs = []sdk.PullRequest{pr}
+	cli.files = []sdk.PullRequestFiles{{Filename: "OWNERS", Additions: "1", Deletions: "0"}}
+	cli.commits = []sdk.PullRequestCommits{{
+		Sha: "head",
+		Commit: &sdk.PullRequestCommitsCommit{
+			Message:   "fix\n\nSigned-off-by: author <author@example.com>",
+			Author:    &sdk.GitUserBasic{Name: "author", Email: "author@example.com"},
+			Committer: &sdk.GitUserBasic{Date: "2021-06-01T08:00:00+08:00"},
+		},
+	}}
+
+	bot := newTestRobot(cli)
+
+	if err := bot.reconcileRepo("org", "repo", cfg, newTestLog()); err != nil {
+		t.Fatal(err)
+	}
+
+	for method, n := range map[string]int{"GetPRCommits": 1, "GetPullRequestChanges": 1, "GetGiteePullRequest": 0} {
+		if cli.calls[method] != n {
+			t.Errorf("%s is called %d times, want %d", method, cli.calls[method], n)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// checkReviewPeriod checks whether the PR has been open long enough for review.
+// The time when the period elapses is recorded, so that the PR can be re-checked then.
+func (m *mergeHelper) checkReviewPeriod(log *logrus.Entry) mergeCheck {
+	c := mergeCheck{Name: m.cfg.message(checkReviewPeriod, nil)}
+
+	d := m.cfg.ReviewPeriod.durationFor(m.pr.GetBase().GetRef())
+	if d <= 0 {
+		c.Passed = true
+
+		return c
+	}
+
+	start, err := m.getReviewStartTime()
+	if err != nil {
+		log.WithError(err).Error("get the start time of review period")
+		c.Detail = m.cfg.message(msgReviewPeriodUnknown, nil)
+
+		return c
+	}
+
+	end := start.Add(d)
+	if c.Passed = !time.Now().Before(end); !c.Passed {
+		m.reviewEndsAt = end
+		c.Detail = m.cfg.message(msgReviewPeriodNotElapsed, msgData{
+			"Duration": d.String(),
+			"Time":     end.Format(time.RFC3339),
+		})
+	}
+
+	return c
+}
+
+// getReviewStartTime returns the time when the PR was created, or the time
+// of the last push if the review period is counted since the last push.
+func (m *mergeHelper) getReviewStartTime() (time.Time, error) {
+	pr, err := m.cli.GetGiteePullRequest(m.org, m.repo, m.pr.Number)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	start, err := time.Parse(time.RFC3339, pr.CreatedAt)
+	if err != nil || !m.cfg.ReviewPeriod.SinceLastPush {
+		return start, err
+	}
+
+	t, err := m.lastPushTime(&pr)
+	if err != nil {
+		return start, err
+	}
+
+	if t.After(start) {
+		start = t
+	}
+
+	return start, nil
+}
+
+// scheduleMerge re-checks the PR when its review period elapses,
+// so that it can be merged without another comment.
+// The timers are kept in memory only, so the reconciler is needed to merge
+// the PRs which are scheduled before the bot restarts.
+func (bot *robot) scheduleMerge(org, repo string, number int32, at time.Time, cfg *botConfig, log *logrus.Entry) {
+	key := genPRKey(org, repo, number)
+
+	bot.timerLock.Lock()
+	defer bot.timerLock.Unlock()
+
+	if t, ok := bot.timers[key]; ok {
+		t.Stop()
+	}
+
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(at), func() {
+		bot.timerLock.Lock()
+		// the timer may have been replaced by a later schedule.
+		if bot.timers[key] == timer {
+			delete(bot.timers, key)
+		}
+		bot.timerLock.Unlock()
+
+		// the config may have changed since the merge was scheduled.
+		if err := bot.recheckPR(org, repo, number, bot.latestConfigFor(org, repo, cfg), log); err != nil {
+			log.WithError(err).Errorf("re-check pr: %s", key)
+		}
+	})
+
+	bot.timers[key] = timer
+}
+
+func (bot *robot) recheckPR(org, repo string, number int32, cfg *botConfig, log *logrus.Entry) error {
+	pr, err := bot.cli.GetGiteePullRequest(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	if pr.State != "open" {
+		return nil
+	}
+
+	h := mergeHelper{
+		cfg:    cfg,
+		org:    org,
+		repo:   repo,
+		cli:    bot.cli,
+		pushes: bot.pushes,
+		pr:     convertToPRHook(&pr),
+	}
+
+	if _, ok := h.canMerge(log); ok {
+		return h.merge(log)
+	}
+
+	if cfg.StatusComment {
+		return bot.updateStatusComment(org, repo, number, cfg, log)
+	}
+
+	return nil
+}
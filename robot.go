@@ -2,6 +2,9 @@ package main
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	sdk "gitee.com/openeuler/go-gitee/gitee"
 	libconfig "github.com/opensourceways/community-robot-lib/config"
@@ -25,21 +28,90 @@ type iClient interface {
 	GetRepoLabels(owner, repo string) ([]sdk.Label, error)
 	MergePR(owner, repo string, number int32, opt sdk.PullRequestMergePutParam) error
 	UpdatePullRequest(org, repo string, number int32, param sdk.PullRequestUpdateParam) (sdk.PullRequest, error)
+	GetPullRequests(org, repo string, opts giteeclient.ListPullRequestOpt) ([]sdk.PullRequest, error)
+	GetRepos(org string) ([]sdk.Project, error)
+	ClosePR(org, repo string, number int32) error
+	GetGiteePullRequest(org, repo string, number int32) (sdk.PullRequest, error)
+	ListPRComments(org, repo string, number int32) ([]sdk.PullRequestComments, error)
+	UpdatePRComment(org, repo string, commentID int32, comment string) error
+	GetBot() (sdk.User, error)
+	GetPRCommits(org, repo string, number int32) ([]sdk.PullRequestCommits, error)
+	GetIssue(org, repo, number string) (sdk.Issue, error)
+	CreateIssueComment(org, repo string, number string, comment string) error
+	UpdateIssue(owner, number string, param sdk.IssueUpdateParam) (sdk.Issue, error)
+	GetPullRequestChanges(org, repo string, number int32) ([]sdk.PullRequestFiles, error)
+	CreatePullRequest(org, repo, title, body, head, base string, canModify bool) (sdk.PullRequest, error)
 }
 
-func newRobot(cli iClient, cacheCli *cache.SDK) *robot {
-	return &robot{cli: cli, cacheCli: cacheCli}
+func newRobot(cli iClient, cacheCli *cache.SDK, dedup *eventDeduplicator, picker *cherryPicker) *robot {
+	return &robot{
+		cli:      cli,
+		cacheCli: cacheCli,
+		dedup:    dedup,
+		picker:   picker,
+		commands: newCommandRegistry(),
+		pushes:   newPushTracker(),
+		timers:   map[string]*time.Timer{},
+
+		contributors: newContributorCache(),
+	}
 }
 
 type robot struct {
 	cli      iClient
 	cacheCli *cache.SDK
+	dedup    *eventDeduplicator
+
+	// picker is nil if /cherry-pick is disabled.
+	picker *cherryPicker
+
+	commands *commandRegistry
+
+	// config is the latest configuration passed by the plugin, which is used by the reconciler.
+	config atomic.Value
+
+	botLogin string
+	botLock  sync.Mutex
+
+	pushes *pushTracker
+
+	// contributors caches the authors of merged PRs for ok-to-test.
+	contributors *contributorCache
+
+	// timers re-check the PRs whose review period has not elapsed.
+	timers    map[string]*time.Timer
+	timerLock sync.Mutex
 }
 
 func (bot *robot) NewPluginConfig() libconfig.PluginConfig {
 	return &configuration{}
 }
 
+func (bot *robot) setConfig(pc libconfig.PluginConfig) {
+	if c, ok := pc.(*configuration); ok && c != nil {
+		bot.config.Store(c)
+	}
+}
+
+// latestConfig returns nil if no event has been received.
+func (bot *robot) latestConfig() *configuration {
+	v, _ := bot.config.Load().(*configuration)
+
+	return v
+}
+
+// latestConfigFor returns the latest config of the repo for the delayed tasks,
+// which falls back to cfg if it is not available.
+func (bot *robot) latestConfigFor(org, repo string, cfg *botConfig) *botConfig {
+	if v := bot.latestConfig(); v != nil {
+		if bc := v.configFor(org, repo); bc != nil {
+			return bc
+		}
+	}
+
+	return cfg
+}
+
 func (bot *robot) getConfig(cfg libconfig.PluginConfig, org, repo string) (*botConfig, error) {
 	c, ok := cfg.(*configuration)
 	if !ok {
@@ -56,21 +128,42 @@ func (bot *robot) getConfig(cfg libconfig.PluginConfig, org, repo string) (*botC
 func (bot *robot) RegisterEventHandler(p libplugin.HandlerRegitster) {
 	p.RegisterPullRequestHandler(bot.handlePREvent)
 	p.RegisterNoteEventHandler(bot.handleNoteEvent)
+	p.RegisterPushEventHandler(bot.handlePushEvent)
 }
 
 func (bot *robot) handlePREvent(e *sdk.PullRequestEvent, pc libconfig.PluginConfig, log *logrus.Entry) error {
+	bot.setConfig(pc)
+
+	key := genPREventKey(e)
+	if bot.isDuplicateEvent(key, log) {
+		return nil
+	}
+
+	err := bot.processPREvent(e, pc, log)
+	bot.finishEvent(key, err, log)
+
+	return err
+}
+
+func (bot *robot) processPREvent(e *sdk.PullRequestEvent, pc libconfig.PluginConfig, log *logrus.Entry) error {
 	org, repo := giteeclient.GetOwnerAndRepoByPREvent(e)
 	cfg, err := bot.getConfig(pc, org, repo)
 	if err != nil {
 		return err
 	}
 
+	bot.pushes.track(e)
+
 	merr := utils.NewMultiErrors()
-	if err := bot.clearLabel(e); err != nil {
+	if err := bot.clearLabel(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.doRetest(e, cfg, log); err != nil {
 		merr.AddError(err)
 	}
 
-	if err := bot.doRetest(e); err != nil {
+	if err := bot.checkOkToTest(e, cfg, log); err != nil {
 		merr.AddError(err)
 	}
 
@@ -78,6 +171,30 @@ func (bot *robot) handlePREvent(e *sdk.PullRequestEvent, pc libconfig.PluginConf
 		merr.AddError(err)
 	}
 
+	if err := bot.handlePRPolicy(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.handleDCO(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.handleChangesLabels(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.handlePRConflict(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.handleNativeReview(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
+	if err := bot.handleMergedCherryPick(e, cfg, log); err != nil {
+		merr.AddError(err)
+	}
+
 	if err := bot.handleLabelUpdate(e, cfg, log); err != nil {
 		merr.AddError(err)
 	}
@@ -86,24 +203,42 @@ func (bot *robot) handlePREvent(e *sdk.PullRequestEvent, pc libconfig.PluginConf
 }
 
 func (bot *robot) handleNoteEvent(e *sdk.NoteEvent, pc libconfig.PluginConfig, log *logrus.Entry) error {
+	bot.setConfig(pc)
+
+	key := genNoteEventKey(e)
+	if bot.isDuplicateEvent(key, log) {
+		return nil
+	}
+
+	err := bot.processNoteEvent(e, pc, log)
+	bot.finishEvent(key, err, log)
+
+	return err
+}
+
+func (bot *robot) processNoteEvent(e *sdk.NoteEvent, pc libconfig.PluginConfig, log *logrus.Entry) error {
 	org, repo := giteeclient.GetOwnerAndRepoByNoteEvent(e)
 	cfg, err := bot.getConfig(pc, org, repo)
 	if err != nil {
 		return err
 	}
 
-	merr := utils.NewMultiErrors()
-	if err := bot.handleLGTM(e, cfg, log); err != nil {
-		merr.AddError(err)
-	}
+	return bot.handleCommands(e, cfg, log)
+}
 
-	if err = bot.handleApprove(e, cfg, log); err != nil {
-		merr.AddError(err)
+func (bot *robot) isDuplicateEvent(key string, log *logrus.Entry) bool {
+	v := bot.dedup.isDuplicate(key)
+	if v {
+		log.Infof("skip the duplicate event: %s", key)
 	}
 
-	if err = bot.handleCheckPR(e, cfg, log); err != nil {
-		merr.AddError(err)
-	}
+	return v
+}
 
-	return merr.Err()
+// finishEvent remembers the event only if it is handled successfully,
+// so that its redelivery can be handled again after a failure.
+func (bot *robot) finishEvent(key string, err error, log *logrus.Entry) {
+	if err := bot.dedup.done(key, err == nil); err != nil {
+		log.WithError(err).Errorf("record event: %s", key)
+	}
 }
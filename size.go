@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"github.com/sirupsen/logrus"
+)
+
+const sizeLabelPrefix = "size/"
+
+// syncSizeLabel labels the PR with its size computed from the changed lines.
+func (bot *robot) syncSizeLabel(pr giteeclient.PRInfo, changes *prChanges, cfg *botConfig, log *logrus.Entry) error {
+	if !cfg.Size.Enabled {
+		return nil
+	}
+
+	files, err := changes.get()
+	if err != nil {
+		return err
+	}
+
+	label := cfg.Size.label(countChangedLines(files, cfg.Size.excludedPaths))
+
+	var toRemove []string
+	for l := range pr.Labels {
+		if strings.HasPrefix(l, sizeLabelPrefix) && l != label {
+			toRemove = append(toRemove, l)
+		}
+	}
+
+	if len(toRemove) > 0 {
+		if err := bot.cli.RemovePRLabels(pr.Org, pr.Repo, pr.Number, toRemove); err != nil {
+			return err
+		}
+	}
+
+	if pr.Labels.Has(label) {
+		return nil
+	}
+
+	if err := bot.createLabelIfNeed(pr.Org, pr.Repo, label); err != nil {
+		log.WithError(err).Errorf("create repo label: %s", label)
+	}
+
+	return bot.cli.AddPRLabel(pr.Org, pr.Repo, pr.Number, label)
+}
+
+// countChangedLines returns the number of added and deleted lines of the files
+// except the excluded ones, such as the generated or vendored files.
+func countChangedLines(files []sdk.PullRequestFiles, excluded []pathGlob) int {
+	n := 0
+
+	for i := range files {
+		f := &files[i]
+		if matchAnyPath(excluded, f.Filename) {
+			continue
+		}
+
+		// the stats are strings in the response of gitee.
+		if v, err := strconv.Atoi(f.Additions); err == nil {
+			n += v
+		}
+
+		if v, err := strconv.Atoi(f.Deletions); err == nil {
+			n += v
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+	"github.com/opensourceways/community-robot-lib/giteeclient"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestSyncSizeLabel(t *testing.T) {
+	file := func(name string, additions, deletions int) sdk.PullRequestFiles {
+		return sdk.PullRequestFiles{
+			Filename:  name,
+			Additions: strconv.Itoa(additions),
+			Deletions: strconv.Itoa(deletions),
+		}
+	}
+
+	cases := []struct {
+		name     string
+		size     sizeConfig
+		files    []sdk.PullRequestFiles
+		labels   []string
+		added    []string
+		removed  []string
+		disabled bool
+	}{
+		{
+			name:  "XS",
+			files: []sdk.PullRequestFiles{file("main.go", 5, 4)},
+			added: []string{"size/XS"},
+		},
+		{
+			name:  "S at the threshold",
+			files: []sdk.PullRequestFiles{file("main.go", 6, 4)},
+			added: []string{"size/S"},
+		},
+		{
+			name:  "XXL",
+			files: []sdk.PullRequestFiles{file("main.go", 600, 0), file("robot.go", 0, 400)},
+			added: []string{"size/XXL"},
+		},
+		{
+			name:  "custom thresholds",
+			size:  sizeConfig{S: 1, M: 2, L: 3, XL: 4, XXL: 5},
+			files: []sdk.PullRequestFiles{file("main.go", 2, 1)},
+			added: []string{"size/L"},
+		},
+		{
+			name: "excluded paths",
+			size: sizeConfig{ExcludedPaths: []string{"vendor/**", "*.pb.go"}},
+			files: []sdk.PullRequestFiles{
+				file("main.go", 3, 0),
+				file("vendor/a/b.go", 1000, 0),
+				file("api/x.pb.go", 500, 0),
+			},
+			added: []string{"size/XS"},
+		},
+		{
+			name:    "resized",
+			files:   []sdk.PullRequestFiles{file("main.go", 50, 0)},
+			labels:  []string{"size/XS"},
+			added:   []string{"size/M"},
+			removed: []string{"size/XS"},
+		},
+		{
+			name:   "unchanged",
+			files:  []sdk.PullRequestFiles{file("main.go", 50, 0)},
+			labels: []string{"size/M"},
+		},
+		{
+			name:     "disabled",
+			files:    []sdk.PullRequestFiles{file("main.go", 50, 0)},
+			disabled: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc.size.Enabled = !tc.disabled
+
+		cfg := &botConfig{Size: tc.size}
+		cfg.setDefault()
+		if err := cfg.validate(); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		cli := newFakeClient()
+		cli.files = tc.files
+		bot := newTestRobot(cli)
+
+		pr := giteeclient.PRInfo{Org: "org", Repo: "repo", Number: 1, Labels: sets.NewString(tc.labels...)}
+		if err := bot.syncSizeLabel(pr, newPRChanges(cli, pr), cfg, newTestLog()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if strings.Join(cli.added, ",") != strings.Join(tc.added, ",") ||
+			strings.Join(cli.removed, ",") != strings.Join(tc.removed, ",") {
+			t.Errorf(
+				"%s: added = %v, removed = %v, want %v, %v",
+				tc.name, cli.added, cli.removed, tc.added, tc.removed,
+			)
+		}
+	}
+}
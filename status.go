@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// statusCommentMark is used to find the status comment of bot in the comments of PR.
+const statusCommentMark = "<!-- review-status -->"
+
+// notify posts the comment about the change of PR,
+// or refreshes the status comment instead if it is enabled.
+func (bot *robot) notify(org, repo string, number int32, cfg *botConfig, comment string, log *logrus.Entry) error {
+	if cfg.StatusComment {
+		return bot.updateStatusComment(org, repo, number, cfg, log)
+	}
+
+	return bot.cli.CreatePRComment(org, repo, number, comment)
+}
+
+// updateStatusComment edits the status comment of PR in place with the latest data of PR.
+// It will be created if not exists.
+func (bot *robot) updateStatusComment(org, repo string, number int32, cfg *botConfig, log *logrus.Entry) error {
+	pr, err := bot.cli.GetGiteePullRequest(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	h := mergeHelper{
+		cfg:    cfg,
+		org:    org,
+		repo:   repo,
+		cli:    bot.cli,
+		pushes: bot.pushes,
+		pr:     convertToPRHook(&pr),
+	}
+
+	checks, ok := h.canMerge(log)
+	content := statusCommentMark + "\n" + cfg.message(
+		commentPRStatus, msgData{"Checks": tableRows(checks), "Ready": ok},
+	)
+
+	id, err := bot.findStatusComment(org, repo, number)
+	if err != nil {
+		return err
+	}
+
+	if id == 0 {
+		return bot.cli.CreatePRComment(org, repo, number, content)
+	}
+
+	return bot.cli.UpdatePRComment(org, repo, id, content)
+}
+
+func (bot *robot) findStatusComment(org, repo string, number int32) (int32, error) {
+	login, err := bot.getBotLogin()
+	if err != nil {
+		return 0, err
+	}
+
+	comments, err := bot.cli.ListPRComments(org, repo, number)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range comments {
+		c := &comments[i]
+		if c.User != nil && c.User.Login == login && strings.Contains(c.Body, statusCommentMark) {
+			return c.Id, nil
+		}
+	}
+
+	return 0, nil
+}
+
+func (bot *robot) getBotLogin() (string, error) {
+	bot.botLock.Lock()
+	defer bot.botLock.Unlock()
+
+	if bot.botLogin != "" {
+		return bot.botLogin, nil
+	}
+
+	u, err := bot.cli.GetBot()
+	if err != nil {
+		return "", err
+	}
+
+	bot.botLogin = u.Login
+
+	return u.Login, nil
+}
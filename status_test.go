@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	sdk "gitee.com/openeuler/go-gitee/gitee"
+)
+
+func TestUpdateStatusComment(t *testing.T) {
+	comment := func(id int32, login, body string) sdk.PullRequestComments {
+		return sdk.PullRequestComments{Id: id, User: &sdk.UserBasic{Login: login}, Body: body}
+	}
+
+	cases := []struct {
+		name     string
+		comments []sdk.PullRequestComments
+		updated  int32
+	}{
+		{name: "created", comments: []sdk.PullRequestComments{comment(1, "alice", "/lgtm")}},
+		{
+			name: "updated",
+			comments: []sdk.PullRequestComments{
+				comment(1, "alice", "/lgtm"),
+				comment(2, "robot", statusCommentMark+"\nold"),
+			},
+			updated: 2,
+		},
+		{
+			name:     "copied by others",
+			comments: []sdk.PullRequestComments{comment(3, "alice", statusCommentMark+"\nold")},
+		},
+	}
+
+	cfg := &botConfig{LgtmCountsRequired: 2, StatusComment: true}
+	cfg.setDefault()
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range cases {
+		cli := newFakeClient()
+		cli.comments = tc.comments
+		cli.pr = sdk.PullRequest{Number: 1, State: "open", Labels: []sdk.Label{{Name: "lgtm-alice"}}}
+
+		bot := newTestRobot(cli)
+
+		if err := bot.updateStatusComment("org", "repo", 1, cfg, newTestLog()); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		var content string
+		if tc.updated == 0 {
+			if len(cli.created) != 1 || len(cli.updated) != 0 {
+				t.Fatalf("%s: created = %d, updated = %v", tc.name, len(cli.created), cli.updated)
+			}
+
+			content = cli.created[0]
+		} else {
+			if len(cli.created) != 0 || len(cli.updated) != 1 {
+				t.Fatalf("%s: created = %d, updated = %v", tc.name, len(cli.created), cli.updated)
+			}
+
+			content = cli.updated[tc.updated]
+		}
+
+		if !strings.HasPrefix(content, statusCommentMark) || !strings.Contains(content, "lgtm-alice") {
+			t.Errorf("%s: content = %s", tc.name, content)
+		}
+	}
+}